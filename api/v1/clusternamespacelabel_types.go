@@ -0,0 +1,162 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"path"
+
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// NamespaceSelector selects Namespaces by their labels and names.
+// A Namespace is selected when it matches the label selector and any of the name globs, an empty selector selects every Namespace.
+type NamespaceSelector struct {
+	// LabelSelector matches the Namespace labels
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// Names are globs matched against the Namespace name, e.g. "tenant-*"
+	// +optional
+	Names []string `json:"names,omitempty"`
+}
+
+// Matches returns true when the Namespace is selected, failing on invalid selectors
+func (s *NamespaceSelector) Matches(namespace *corev1.Namespace) (bool, error) {
+	if s.LabelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(s.LabelSelector)
+		if err != nil {
+			return false, err
+		}
+		if !selector.Matches(labels.Set(namespace.GetLabels())) {
+			return false, nil
+		}
+	}
+
+	if len(s.Names) == 0 {
+		return true, nil
+	}
+	for _, name := range s.Names {
+		matched, err := path.Match(name, namespace.GetName())
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// ClusterNamespaceLabelSpec defines the desired state of ClusterNamespaceLabel
+type ClusterNamespaceLabelSpec struct {
+	// NamespaceSelector selects the Namespaces to label
+	NamespaceSelector NamespaceSelector `json:"namespaceSelector"`
+
+	// Labels to set on every selected Namespace, they take precedence over the labels of NamespaceLabels
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// ClusterNamespaceLabelStatus defines the observed state of ClusterNamespaceLabel
+type ClusterNamespaceLabelStatus struct {
+	// ObservedGeneration is the ClusterNamespaceLabel generation the status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions describe the current state of the ClusterNamespaceLabel
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// MatchedNamespaces are the names of the Namespaces selected by the ClusterNamespaceLabel
+	// +optional
+	MatchedNamespaces []string `json:"matchedNamespaces,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ClusterNamespaceLabel is the Schema for the clusternamespacelabels API
+type ClusterNamespaceLabel struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterNamespaceLabelSpec   `json:"spec,omitempty"`
+	Status ClusterNamespaceLabelStatus `json:"status,omitempty"`
+}
+
+func (cnl *ClusterNamespaceLabel) IsBeingDeleted() bool {
+	return !cnl.ObjectMeta.DeletionTimestamp.IsZero()
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterNamespaceLabelList contains a list of ClusterNamespaceLabel
+type ClusterNamespaceLabelList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterNamespaceLabel `json:"items"`
+}
+
+// GetLabelsFor merges the labels of the ClusterNamespaceLabels selecting the Namespace, the oldest wins conflicts.
+// It returns the labels and, for each key, the name of the ClusterNamespaceLabel it comes from.
+// ClusterNamespaceLabels being deleted or with invalid selectors are ignored.
+func (cnls *ClusterNamespaceLabelList) GetLabelsFor(namespace *corev1.Namespace) (map[string]string, map[string]string) {
+	items := make([]*ClusterNamespaceLabel, 0, len(cnls.Items))
+	for i := range cnls.Items {
+		items = append(items, &cnls.Items[i])
+	}
+	slices.SortFunc(items, func(a, b *ClusterNamespaceLabel) bool {
+		if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+			return a.CreationTimestamp.Before(&b.CreationTimestamp)
+		}
+		return a.Name < b.Name
+	})
+
+	clusterLabels := make(map[string]string)
+	owners := make(map[string]string)
+	for _, item := range items {
+		if item.IsBeingDeleted() {
+			continue
+		}
+		if matches, err := item.Spec.NamespaceSelector.Matches(namespace); err != nil || !matches {
+			continue
+		}
+		for key, value := range item.Spec.Labels {
+			if _, exists := clusterLabels[key]; exists {
+				continue
+			}
+			clusterLabels[key] = value
+			owners[key] = item.Name
+		}
+	}
+
+	return clusterLabels, owners
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterNamespaceLabel{}, &ClusterNamespaceLabelList{})
+}
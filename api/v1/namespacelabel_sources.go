@@ -0,0 +1,70 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"idandaniel.io/namespacelabel-demo/common/protected"
+)
+
+// IsSharedWith returns true when the NamespaceLabels of the Namespace may take labels from the ConfigMap: it is in the
+// same Namespace, or a glob of its SharedWithAnnotation matches the Namespace
+func IsSharedWith(configMap metav1.Object, namespace string) bool {
+	if configMap.GetNamespace() == namespace {
+		return true
+	}
+	for _, glob := range protected.SplitList(configMap.GetAnnotations()[SharedWithAnnotation]) {
+		if matched, err := path.Match(glob, namespace); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Labels returns the data of the ConfigMap as labels with the prefix of the source, and the messages of
+// the keys and values which are not valid labels keyed by the label key
+func (s *LabelsSource) Labels(data map[string]string) (map[string]string, map[string]string) {
+	reference := s.Reference("")
+	labels := make(map[string]string, len(data))
+	var invalid map[string]string
+	for key, value := range data {
+		labelKey := s.Prefix + key
+		labels[labelKey] = value
+
+		var msgs []string
+		for _, err := range metav1validation.ValidateLabelName(labelKey, field.NewPath("data")) {
+			msgs = append(msgs, err.Detail)
+		}
+		msgs = append(msgs, validation.IsValidLabelValue(value)...)
+		if len(msgs) == 0 {
+			continue
+		}
+		if invalid == nil {
+			invalid = make(map[string]string)
+		}
+		invalid[labelKey] = fmt.Sprintf("The key %s of ConfigMap %s is not a valid label: %s", key, reference.Name, strings.Join(msgs, "; "))
+	}
+	return labels, invalid
+}
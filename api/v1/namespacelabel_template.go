@@ -0,0 +1,128 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Functions available to label value templates, named and ordered like their sprig counterparts
+var templateFuncs = template.FuncMap{
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix string, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix string, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old string, new string, s string) string { return strings.ReplaceAll(s, old, new) },
+	"default": func(defaultValue string, value string) string {
+		if value == "" {
+			return defaultValue
+		}
+		return value
+	},
+}
+
+// Data label value templates are rendered with
+type templateData struct {
+	Namespace templateNamespace
+}
+
+type templateNamespace struct {
+	Name        string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// ParseLabelTemplate parses a label value template. Missing keys of the Namespace labels and annotations fail the
+// rendering, use index and default to render missing keys as a default value.
+func ParseLabelTemplate(value string) (*template.Template, error) {
+	return template.New("label").Funcs(templateFuncs).Option("missingkey=error").Parse(value)
+}
+
+// Renders a label value template with the metadata of the Namespace, failing on invalid label values
+func renderLabelTemplate(value string, namespace *corev1.Namespace) (string, error) {
+	tmpl, err := ParseLabelTemplate(value)
+	if err != nil {
+		return "", err
+	}
+
+	var rendered strings.Builder
+	data := templateData{Namespace: templateNamespace{
+		Name:        namespace.Name,
+		Labels:      namespace.Labels,
+		Annotations: namespace.Annotations,
+	}}
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", err
+	}
+
+	if msgs := validation.IsValidLabelValue(rendered.String()); len(msgs) > 0 {
+		return rendered.String(), fmt.Errorf("rendered the invalid label value %q: %s", rendered.String(), strings.Join(msgs, "; "))
+	}
+	return rendered.String(), nil
+}
+
+// RenderLabels returns the labels of the NamespaceLabel with their values rendered against the Namespace when it is
+// templated, and the messages of the labels which failed to render keyed by the label key. Failed labels keep their
+// template, or the invalid value they rendered to. The values of RemoveOnly labels are ignored and never rendered.
+func (nl *NamespaceLabel) RenderLabels(namespace *corev1.Namespace) (map[string]string, map[string]string) {
+	if !nl.Spec.Templated {
+		return nl.Spec.Labels, nil
+	}
+
+	labels := make(map[string]string, len(nl.Spec.Labels))
+	var failed map[string]string
+	for key, value := range nl.Spec.Labels {
+		if nl.ApplyPolicy(key) == RemoveOnly {
+			labels[key] = value
+			continue
+		}
+		rendered, err := renderLabelTemplate(value, namespace)
+		if err != nil {
+			if failed == nil {
+				failed = make(map[string]string)
+			}
+			failed[key] = fmt.Sprintf("Failed to render the label template: %v", err)
+			if rendered == "" {
+				rendered = value
+			}
+		}
+		labels[key] = rendered
+	}
+	return labels, failed
+}
+
+// RenderLabels returns a copy of the list with the labels of the templated NamespaceLabels rendered against the
+// Namespace, and the messages of the labels which failed to render keyed by the NamespaceLabel name and the label key
+func (nls *NamespaceLabelList) RenderLabels(namespace *corev1.Namespace) (*NamespaceLabelList, map[string]map[string]string) {
+	namespaceLabels := nls.DeepCopy()
+	failed := make(map[string]map[string]string)
+	for i := range namespaceLabels.Items {
+		nl := &namespaceLabels.Items[i]
+		labels, failedLabels := nl.RenderLabels(namespace)
+		nl.Spec.Labels = labels
+		if len(failedLabels) > 0 {
+			failed[nl.Name] = failedLabels
+		}
+	}
+	return namespaceLabels, failed
+}
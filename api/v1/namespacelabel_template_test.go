@@ -0,0 +1,94 @@
+package v1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("NamespaceLabel templates", func() {
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "team-payments",
+		Labels:      map[string]string{"tier": "gold"},
+		Annotations: map[string]string{"owner": "alice"},
+	}}
+
+	newNamespaceLabel := func(templated bool, labels map[string]string) *NamespaceLabel {
+		return &NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: "templated", Namespace: namespace.Name},
+			Spec:       NamespaceLabelSpec{Labels: labels, Templated: templated},
+		}
+	}
+
+	It("Should render the labels with the Namespace metadata", func() {
+		nl := newNamespaceLabel(true, map[string]string{
+			"owner":    "{{ .Namespace.Annotations.owner }}",
+			"env":      `{{ trimPrefix "team-" .Namespace.Name | upper }}`,
+			"tier":     "{{ .Namespace.Labels.tier }}-plus",
+			"contact":  `{{ index .Namespace.Annotations "contact" | default "none" }}`,
+			"constant": "static",
+		})
+
+		labels, failed := nl.RenderLabels(namespace)
+		Expect(failed).To(BeEmpty())
+		Expect(labels).To(Equal(map[string]string{
+			"owner":    "alice",
+			"env":      "PAYMENTS",
+			"tier":     "gold-plus",
+			"contact":  "none",
+			"constant": "static",
+		}))
+	})
+
+	It("Should report the labels which fail to render or render to invalid values", func() {
+		nl := newNamespaceLabel(true, map[string]string{
+			"missing": "{{ .Namespace.Annotations.contact }}",
+			"invalid": "{{ .Namespace.Annotations.owner }} smith",
+			"owner":   "{{ .Namespace.Annotations.owner }}",
+		})
+
+		labels, failed := nl.RenderLabels(namespace)
+		Expect(failed).To(HaveLen(2))
+		Expect(failed["missing"]).To(ContainSubstring("Failed to render the label template"))
+		Expect(failed["invalid"]).To(ContainSubstring(`rendered the invalid label value "alice smith"`))
+		Expect(labels).To(Equal(map[string]string{
+			"missing": "{{ .Namespace.Annotations.contact }}",
+			"invalid": "alice smith",
+			"owner":   "alice",
+		}))
+	})
+
+	It("Should not render the labels of NamespaceLabels which are not templated", func() {
+		list := &NamespaceLabelList{Items: []NamespaceLabel{*newNamespaceLabel(false, map[string]string{"owner": "{{ .Namespace.Name }}"})}}
+
+		rendered, failed := list.RenderLabels(namespace)
+		Expect(failed).To(BeEmpty())
+		Expect(rendered.Items[0].Spec.Labels).To(Equal(map[string]string{"owner": "{{ .Namespace.Name }}"}))
+	})
+
+	It("Should prefix the keys of label sources and report the invalid ones", func() {
+		source := &LabelsSource{Prefix: "cost.example.com/", ConfigMapRef: &SourceReference{Name: "costs"}}
+		Expect(source.Reference("tenant").String()).To(Equal("tenant/costs"))
+
+		labels, invalid := source.Labels(map[string]string{"center": "1234", "owner": "finance team"})
+		Expect(labels).To(Equal(map[string]string{"cost.example.com/center": "1234", "cost.example.com/owner": "finance team"}))
+		Expect(invalid).To(HaveLen(1))
+		Expect(invalid["cost.example.com/owner"]).To(ContainSubstring("The key owner of ConfigMap costs is not a valid label"))
+
+		source = &LabelsSource{ConfigMapRef: &SourceReference{Name: "costs", Namespace: "finance"}}
+		Expect(source.Reference("tenant").String()).To(Equal("finance/costs"))
+	})
+
+	It("Should only share the ConfigMaps of other Namespaces matching their shared-with globs", func() {
+		configMap := &metav1.ObjectMeta{Name: "costs", Namespace: "finance"}
+		Expect(IsSharedWith(configMap, "finance")).To(BeTrue())
+		Expect(IsSharedWith(configMap, "tenant-a")).To(BeFalse())
+
+		configMap.Annotations = map[string]string{SharedWithAnnotation: "tenant-*, payments"}
+		Expect(IsSharedWith(configMap, "tenant-a")).To(BeTrue())
+		Expect(IsSharedWith(configMap, "payments")).To(BeTrue())
+		Expect(IsSharedWith(configMap, "checkout")).To(BeFalse())
+	})
+})
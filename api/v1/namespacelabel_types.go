@@ -17,30 +17,306 @@ limitations under the License.
 package v1
 
 import (
+	"strings"
+	"time"
+
 	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// ConflictPolicy decides how a NamespaceLabel competes over a label key with other NamespaceLabels
+// +kubebuilder:validation:Enum=FirstWriterWins;HighestPriority;Refuse
+type ConflictPolicy string
+
+const (
+	// FirstWriterWins gives the label to the oldest NamespaceLabel
+	FirstWriterWins ConflictPolicy = "FirstWriterWins"
+	// HighestPriority gives the label to the NamespaceLabel with the highest priority, the oldest one wins ties
+	HighestPriority ConflictPolicy = "HighestPriority"
+	// Refuse never applies a label other NamespaceLabels set to a different value
+	Refuse ConflictPolicy = "Refuse"
+)
+
+// Mode decides whether a NamespaceLabel changes its Namespace
+// +kubebuilder:validation:Enum=Enforce;Audit
+type Mode string
+
+const (
+	// Enforce applies the labels and annotations to the Namespace
+	Enforce Mode = "Enforce"
+	// Audit only reports the changes the NamespaceLabel would make in its status and events
+	Audit Mode = "Audit"
+)
+
+// ApplyPolicy decides how a label of a NamespaceLabel is applied to the Namespace
+// +kubebuilder:validation:Enum=Enforce;ApplyIfAbsent;RemoveOnly
+type ApplyPolicy string
+
+const (
+	// ApplyEnforce sets the label to its value and keeps it there
+	ApplyEnforce ApplyPolicy = "Enforce"
+	// ApplyIfAbsent sets the label only when the Namespace does not have it, an existing value is never changed
+	ApplyIfAbsent ApplyPolicy = "ApplyIfAbsent"
+	// RemoveOnly removes the label from the Namespace whoever set it, its value is ignored
+	RemoveOnly ApplyPolicy = "RemoveOnly"
+)
+
+// LabelsSource is a ConfigMap whose data are set as labels of the Namespace
+type LabelsSource struct {
+	// Prefix is prepended to every key of the source
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// ConfigMapRef references a ConfigMap whose data are set as labels
+	ConfigMapRef *SourceReference `json:"configMapRef"`
+}
+
+// SourceReference references a ConfigMap
+type SourceReference struct {
+	// Name of the ConfigMap
+	Name string `json:"name"`
+
+	// Namespace of the ConfigMap, defaults to the Namespace of the NamespaceLabel. A ConfigMap of another Namespace
+	// must share its labels with the Namespace of the NamespaceLabel in its SharedWithAnnotation, and referencing it
+	// requires the permission to get the ConfigMap there.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
 // NamespaceLabelSpec defines the desired state of NamespaceLabel
 type NamespaceLabelSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	// Foo is an example field of NamespaceLabel. Edit namespacelabel_types.go to remove/update
+	// Labels to set on the Namespace the NamespaceLabel is in
 	Labels map[string]string `json:"labels,omitempty"`
+
+	// LabelsFrom sets the data of ConfigMaps as labels of the Namespace. Later sources override the keys
+	// of earlier ones, and the Labels override them all.
+	// +optional
+	LabelsFrom []LabelsSource `json:"labelsFrom,omitempty"`
+
+	// Annotations to set on the Namespace the NamespaceLabel is in, they are merged, protected and owned like the labels
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Priority of the NamespaceLabel, used when every conflicting NamespaceLabel has the HighestPriority policy
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// ConflictPolicy decides which NamespaceLabel wins when several NamespaceLabels set the same key to different values.
+	// Priorities are compared only when all the NamespaceLabels competing over a key use HighestPriority,
+	// otherwise the oldest NamespaceLabel wins. NamespaceLabels with the Refuse policy never apply a conflicting key.
+	// +kubebuilder:default=FirstWriterWins
+	// +optional
+	ConflictPolicy ConflictPolicy `json:"conflictPolicy,omitempty"`
+
+	// Mode is Enforce to apply the labels and annotations, or Audit to report the changes they would make to the
+	// Namespace without applying them. Switching to Audit keeps the labels and annotations the NamespaceLabel applied
+	// with their current values, until it is enforced again or deleted.
+	// +kubebuilder:default=Enforce
+	// +optional
+	Mode Mode `json:"mode,omitempty"`
+
+	// Templated renders the label values as Go templates with the name, labels and annotations of the Namespace,
+	// e.g. `{{ .Namespace.Annotations.owner }}` or `{{ trimPrefix "team-" .Namespace.Name }}`.
+	// Labels which fail to render or render to invalid values are skipped.
+	// +optional
+	Templated bool `json:"templated,omitempty"`
+
+	// ApplyPolicies sets the apply policy of the labels, keyed by the label key. Labels without a policy are enforced.
+	// Every key must be one of the Labels.
+	// +optional
+	ApplyPolicies map[string]ApplyPolicy `json:"applyPolicies,omitempty"`
+
+	// RemoveLabels are label keys, or key prefixes ending with `*`, removed from the Namespace on every sync whoever
+	// set them. Protected labels and the labels set by NamespaceLabels and ClusterNamespaceLabels are kept.
+	// +optional
+	RemoveLabels []string `json:"removeLabels,omitempty"`
+
+	// ResyncInterval is how often the NamespaceLabel is synced again when nothing changed, correcting the drift
+	// missed events left on the Namespace. It overrides the --resync-period of the manager, and up to 10% of jitter
+	// is added so the NamespaceLabels are not synced all at once.
+	// +optional
+	ResyncInterval *metav1.Duration `json:"resyncInterval,omitempty"`
+}
+
+// MinResyncInterval is the shortest ResyncInterval of a NamespaceLabel
+const MinResyncInterval = time.Minute
+
+// Condition types reported in NamespaceLabelStatus.Conditions
+const (
+	// ConditionReady is True when every label and annotation of the NamespaceLabel is applied to the Namespace
+	ConditionReady = "Ready"
+	// ConditionSynced is True when the last sync with the Namespace succeeded
+	ConditionSynced = "Synced"
+	// ConditionConflicted is True when some of the labels or annotations were skipped
+	ConditionConflicted = "Conflicted"
+	// ConditionPaused is True when the reconciliation is paused by the PausedAnnotation
+	ConditionPaused = "Paused"
+)
+
+// PausedAnnotation pauses the reconciliation of the NamespaceLabel or of every NamespaceLabel in the Namespace when "true"
+const PausedAnnotation = "idandaniel.idandaniel.io/paused"
+
+// SharedWithAnnotation lists the globs of the Namespaces whose NamespaceLabels may take labels from the ConfigMap,
+// e.g. "tenant-*,payments"
+const SharedWithAnnotation = "idandaniel.idandaniel.io/shared-with"
+
+// Reasons used in NamespaceLabelStatus conditions and skipped labels
+const (
+	ReasonLabelsApplied   = "LabelsApplied"
+	ReasonLabelsSkipped   = "LabelsSkipped"
+	ReasonSyncSucceeded   = "SyncSucceeded"
+	ReasonSyncFailed      = "SyncFailed"
+	ReasonNoConflicts     = "NoConflicts"
+	ReasonLabelConflict   = "LabelConflict"
+	ReasonLabelOverridden = "LabelOverridden"
+	ReasonConflictRefused = "ConflictRefused"
+	ReasonLabelProtected  = "LabelProtected"
+	// ReasonOwnershipConflict means other field managers own Namespace labels with different values
+	ReasonOwnershipConflict = "OwnershipConflict"
+	// ReasonPolicyDenied means a NamespaceLabelPolicy does not allow the label
+	ReasonPolicyDenied = "PolicyDenied"
+	// ReasonAudit means the changes were computed in audit mode and not applied
+	ReasonAudit = "Audit"
+	// ReasonTemplateFailed means the label value template failed to render or rendered to an invalid value
+	ReasonTemplateFailed = "TemplateFailed"
+	// ReasonInvalidSourceLabel means the key or the value of a ConfigMap is not a valid label
+	ReasonInvalidSourceLabel = "InvalidSourceLabel"
+	// ReasonSourceNotFound means a ConfigMap of the labelsFrom does not exist
+	ReasonSourceNotFound = "SourceNotFound"
+	// ReasonSourceNotShared means a ConfigMap of the labelsFrom in another Namespace is not shared with the Namespace
+	ReasonSourceNotShared = "SourceNotShared"
+	// ReasonPaused means the PausedAnnotation is set on the NamespaceLabel or its Namespace
+	ReasonPaused = "Paused"
+	// ReasonReconciling means the reconciliation is not paused
+	ReasonReconciling = "Reconciling"
+	// ReasonSyncQueued means the ClusterNamespaceLabel queued the sync of the Namespaces it selects
+	ReasonSyncQueued = "SyncQueued"
+)
+
+// Reasons of the events emitted on NamespaceLabels and Namespaces when the Namespace changes
+const (
+	ReasonLabelsAdded        = "LabelsAdded"
+	ReasonLabelsRemoved      = "LabelsRemoved"
+	ReasonLabelsRestored     = "LabelsRestored"
+	ReasonOrphansRemoved     = "OrphansRemoved"
+	ReasonAnnotationsAdded   = "AnnotationsAdded"
+	ReasonAnnotationsRemoved = "AnnotationsRemoved"
+)
+
+// SkippedLabel is a label or annotation of the NamespaceLabel that was not applied to the Namespace
+type SkippedLabel struct {
+	// Key of the skipped label or annotation
+	Key string `json:"key"`
+
+	// Value the NamespaceLabel asked for
+	Value string `json:"value,omitempty"`
+
+	// Reason is a CamelCase reason for skipping the key
+	Reason string `json:"reason"`
+
+	// Message is a human readable explanation for skipping the key
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// RemovedLabel is a label removed from the Namespace by the RemoveLabels of a NamespaceLabel
+type RemovedLabel struct {
+	// Key of the removed label
+	Key string `json:"key"`
+
+	// Value the label had when it was removed
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// RemovedAt is when the label was last removed
+	RemovedAt metav1.Time `json:"removedAt"`
 }
 
 // NamespaceLabelStatus defines the observed state of NamespaceLabel
 type NamespaceLabelStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// ObservedGeneration is the NamespaceLabel generation the status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastSyncTime is when the labels were last synced with the Namespace, it is updated at most once a minute
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// Conditions describe the current state of the NamespaceLabel (Ready, Synced, Conflicted and Paused)
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// AppliedLabels are the labels of the NamespaceLabel currently set on the Namespace
+	// +optional
+	AppliedLabels map[string]string `json:"appliedLabels,omitempty"`
+
+	// SkippedLabels are the labels of the NamespaceLabel which were not applied to the Namespace
+	// +optional
+	SkippedLabels []SkippedLabel `json:"skippedLabels,omitempty"`
+
+	// ApplyPolicies are the apply policies the labels of the NamespaceLabel were applied with, keyed by the label key.
+	// A label overridden by another NamespaceLabel reports the policy of the winner.
+	// +optional
+	ApplyPolicies map[string]ApplyPolicy `json:"applyPolicies,omitempty"`
+
+	// RemovedLabels are the labels the RemoveLabels of the NamespaceLabel removed from the Namespace
+	// +optional
+	// +listType=map
+	// +listMapKey=key
+	RemovedLabels []RemovedLabel `json:"removedLabels,omitempty"`
+
+	// AppliedAnnotations are the annotations of the NamespaceLabel currently set on the Namespace
+	// +optional
+	AppliedAnnotations map[string]string `json:"appliedAnnotations,omitempty"`
+
+	// SkippedAnnotations are the annotations of the NamespaceLabel which were not applied to the Namespace
+	// +optional
+	SkippedAnnotations []SkippedLabel `json:"skippedAnnotations,omitempty"`
+
+	// PendingChanges are the changes the sync would make to the Namespace, reported in audit mode where
+	// the applied and skipped labels and annotations are the ones the sync would apply and skip
+	// +optional
+	PendingChanges *PendingChanges `json:"pendingChanges,omitempty"`
+}
+
+// PendingChanges are the changes to the labels and annotations of a Namespace computed in audit mode
+type PendingChanges struct {
+	// AddedLabels are the labels which would be added or changed
+	// +optional
+	AddedLabels map[string]string `json:"addedLabels,omitempty"`
+
+	// RemovedLabels are the label keys which would be removed
+	// +optional
+	RemovedLabels []string `json:"removedLabels,omitempty"`
+
+	// AddedAnnotations are the annotations which would be added or changed
+	// +optional
+	AddedAnnotations map[string]string `json:"addedAnnotations,omitempty"`
+
+	// RemovedAnnotations are the annotation keys which would be removed
+	// +optional
+	RemovedAnnotations []string `json:"removedAnnotations,omitempty"`
 }
 
 //+kubebuilder:object:root=true
+//+kubebuilder:storageversion
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Synced",type=string,JSONPath=`.status.conditions[?(@.type=="Synced")].status`
+//+kubebuilder:printcolumn:name="Conflicted",type=string,JSONPath=`.status.conditions[?(@.type=="Conflicted")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // NamespaceLabel is the Schema for the namespacelabels API
 type NamespaceLabel struct {
@@ -55,6 +331,73 @@ func (nl *NamespaceLabel) IsBeingDeleted() bool {
 	return !nl.ObjectMeta.DeletionTimestamp.IsZero()
 }
 
+// Reference returns the namespaced name of the referenced ConfigMap, in the given Namespace by default
+func (s *LabelsSource) Reference(namespace string) types.NamespacedName {
+	ref := s.ConfigMapRef
+	if ref == nil {
+		return types.NamespacedName{}
+	}
+	if ref.Namespace != "" {
+		namespace = ref.Namespace
+	}
+	return types.NamespacedName{Name: ref.Name, Namespace: namespace}
+}
+
+// IsPaused returns true when the PausedAnnotation of the NamespaceLabel or Namespace is "true"
+func IsPaused(object metav1.Object) bool {
+	return object.GetAnnotations()[PausedAnnotation] == "true"
+}
+
+// ApplyPolicy returns the apply policy of a label of the NamespaceLabel
+func (nl *NamespaceLabel) ApplyPolicy(key string) ApplyPolicy {
+	if policy, exists := nl.Spec.ApplyPolicies[key]; exists {
+		return policy
+	}
+	return ApplyEnforce
+}
+
+// AppliedLabels returns the labels of the NamespaceLabel which are applied to the Namespace, every label except the
+// RemoveOnly ones. RemoveOnly labels never compete over a key nor are checked against the NamespaceLabelPolicies.
+func (nl *NamespaceLabel) AppliedLabels() map[string]string {
+	labels := nl.Spec.Labels
+	for key := range nl.Spec.Labels {
+		if nl.ApplyPolicy(key) != RemoveOnly {
+			continue
+		}
+		if len(labels) == len(nl.Spec.Labels) {
+			labels = maps.Clone(nl.Spec.Labels)
+		}
+		delete(labels, key)
+	}
+	return labels
+}
+
+// RemovesLabel returns true when the label key is a RemoveOnly label or matches one of the RemoveLabels of the NamespaceLabel
+func (nl *NamespaceLabel) RemovesLabel(key string) bool {
+	if _, exists := nl.Spec.Labels[key]; exists && nl.ApplyPolicy(key) == RemoveOnly {
+		return true
+	}
+	for _, removed := range nl.Spec.RemoveLabels {
+		if MatchesRemoval(removed, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesRemoval returns true when the label key is the removed key, or starts with the removed prefix ending with `*`
+func MatchesRemoval(removed string, key string) bool {
+	if strings.HasSuffix(removed, "*") {
+		return strings.HasPrefix(key, strings.TrimSuffix(removed, "*"))
+	}
+	return key == removed
+}
+
+// IsAudited returns true when the NamespaceLabel only reports the changes it would make
+func (nl *NamespaceLabel) IsAudited() bool {
+	return nl.Spec.Mode == Audit
+}
+
 //+kubebuilder:object:root=true
 
 // NamespaceLabelList contains a list of NamespaceLabel
@@ -64,17 +407,231 @@ type NamespaceLabelList struct {
 	Items           []NamespaceLabel `json:"items"`
 }
 
-func (nls *NamespaceLabelList) GetLabels() map[string]string {
-	labelsToAdd := make(map[string]string)
+// LabelConflict is a label or annotation key a NamespaceLabel lost to other resources
+// +kubebuilder:object:generate=false
+type LabelConflict struct {
+	// Key of the conflicting label or annotation
+	Key string
+	// Value the losing NamespaceLabel asked for
+	Value string
+	// Winner is the name of the resource whose value was applied, empty when none was applied
+	Winner string
+	// WinnerKind is the kind of the resource whose value was applied
+	WinnerKind string
+	// Refused is true when the losing NamespaceLabel gave up the key because of its Refuse policy
+	Refused bool
+}
+
+// Returns true when the NamespaceLabel should win the conflict over the other NamespaceLabel
+func (nl *NamespaceLabel) isOlderThan(other *NamespaceLabel) bool {
+	if !nl.CreationTimestamp.Equal(&other.CreationTimestamp) {
+		return nl.CreationTimestamp.Before(&other.CreationTimestamp)
+	}
+	return nl.Name < other.Name
+}
+
+// Sorts the NamespaceLabels by creation time, the name is the tiebreaker
+func (nls *NamespaceLabelList) sortedItems() []*NamespaceLabel {
+	items := make([]*NamespaceLabel, 0, len(nls.Items))
+	for i := range nls.Items {
+		items = append(items, &nls.Items[i])
+	}
+	slices.SortFunc(items, func(a, b *NamespaceLabel) bool {
+		return a.isOlderThan(b)
+	})
+	return items
+}
+
+// Picks the NamespaceLabel whose value is applied out of NamespaceLabels sorted by age
+func conflictWinner(contenders []*NamespaceLabel) *NamespaceLabel {
+	if len(contenders) == 0 {
+		return nil
+	}
+
+	for _, contender := range contenders {
+		if contender.Spec.ConflictPolicy != HighestPriority {
+			return contenders[0]
+		}
+	}
+
+	winner := contenders[0]
+	for _, contender := range contenders[1:] {
+		if contender.Spec.Priority > winner.Spec.Priority {
+			winner = contender
+		}
+	}
+	return winner
+}
+
+// ResolveLabels merges the labels of all the NamespaceLabels, resolving conflicting keys by their ConflictPolicy.
+// It returns the labels to apply and the conflicts every NamespaceLabel lost, keyed by its name.
+func (nls *NamespaceLabelList) ResolveLabels() (map[string]string, map[string][]LabelConflict) {
+	return nls.resolve((*NamespaceLabel).AppliedLabels)
+}
+
+// ResolveAnnotations merges the annotations of all the NamespaceLabels the same way ResolveLabels merges the labels
+func (nls *NamespaceLabelList) ResolveAnnotations() (map[string]string, map[string][]LabelConflict) {
+	return nls.resolve(func(nl *NamespaceLabel) map[string]string { return nl.Spec.Annotations })
+}
+
+// Merges the keys fieldOf returns for every NamespaceLabel, resolving conflicting keys by their ConflictPolicy
+func (nls *NamespaceLabelList) resolve(fieldOf func(*NamespaceLabel) map[string]string) (map[string]string, map[string][]LabelConflict) {
+	claims := make(map[string][]*NamespaceLabel)
+	for _, item := range nls.sortedItems() {
+		for key := range fieldOf(item) {
+			claims[key] = append(claims[key], item)
+		}
+	}
+
+	labels := make(map[string]string)
+	conflicts := make(map[string][]LabelConflict)
+	for key, claimants := range claims {
+		value := fieldOf(claimants[0])[key]
+		isConflicted := false
+		for _, claimant := range claimants[1:] {
+			if fieldOf(claimant)[key] != value {
+				isConflicted = true
+				break
+			}
+		}
+		if !isConflicted {
+			labels[key] = value
+			continue
+		}
+
+		var contenders []*NamespaceLabel
+		for _, claimant := range claimants {
+			if claimant.Spec.ConflictPolicy != Refuse {
+				contenders = append(contenders, claimant)
+			}
+		}
+
+		winner := conflictWinner(contenders)
+		winnerName, winnerKind := "", ""
+		if winner != nil {
+			winnerName, winnerKind = winner.Name, "NamespaceLabel"
+			labels[key] = fieldOf(winner)[key]
+		}
 
-	for _, item := range nls.Items {
-		maps.Copy(labelsToAdd, item.Spec.Labels)
+		for _, claimant := range claimants {
+			if winner != nil && fieldOf(claimant)[key] == labels[key] {
+				continue
+			}
+			conflicts[claimant.Name] = append(conflicts[claimant.Name], LabelConflict{
+				Key:        key,
+				Value:      fieldOf(claimant)[key],
+				Winner:     winnerName,
+				WinnerKind: winnerKind,
+				Refused:    claimant.Spec.ConflictPolicy == Refuse,
+			})
+		}
 	}
 
+	sortConflicts(conflicts)
+	return labels, conflicts
+}
+
+// OverrideLabels gives precedence to the labels of other resources over the labels resolved by ResolveLabels.
+// owners maps every overriding key to the name of the resource of the given kind it comes from,
+// the NamespaceLabels asking for a different value lose the key.
+func (nls *NamespaceLabelList) OverrideLabels(labels map[string]string, conflicts map[string][]LabelConflict, overrides map[string]string, owners map[string]string, kind string) {
+	for key, value := range overrides {
+		labels[key] = value
+
+		for _, item := range nls.Items {
+			itemValue, exists := item.AppliedLabels()[key]
+			if !exists || itemValue == value {
+				continue
+			}
+
+			// The key may already be lost to another NamespaceLabel
+			itemConflicts := conflicts[item.Name]
+			if index := slices.IndexFunc(itemConflicts, func(conflict LabelConflict) bool { return conflict.Key == key }); index >= 0 {
+				itemConflicts = slices.Delete(itemConflicts, index, index+1)
+			}
+			conflicts[item.Name] = append(itemConflicts, LabelConflict{
+				Key:        key,
+				Value:      itemValue,
+				Winner:     owners[key],
+				WinnerKind: kind,
+			})
+		}
+	}
+
+	sortConflicts(conflicts)
+}
+
+// ApplyPolicies returns the apply policy of every label resolved by ResolveLabels and OverrideLabels.
+// A label gets the policy of the oldest NamespaceLabel which did not lose it, the labels of other resources are enforced.
+func (nls *NamespaceLabelList) ApplyPolicies(labels map[string]string, conflicts map[string][]LabelConflict) map[string]ApplyPolicy {
+	policies := make(map[string]ApplyPolicy, len(labels))
+	for _, item := range nls.sortedItems() {
+		for key, value := range item.AppliedLabels() {
+			if _, decided := policies[key]; decided || labels[key] != value {
+				continue
+			}
+			if slices.IndexFunc(conflicts[item.Name], func(conflict LabelConflict) bool { return conflict.Key == key }) >= 0 {
+				continue
+			}
+			policies[key] = item.ApplyPolicy(key)
+		}
+	}
+	for key := range labels {
+		if _, decided := policies[key]; !decided {
+			policies[key] = ApplyEnforce
+		}
+	}
+	return policies
+}
+
+// RemovesLabel returns true when the label key is removed by any of the NamespaceLabels
+func (nls *NamespaceLabelList) RemovesLabel(key string) bool {
+	for i := range nls.Items {
+		if nls.Items[i].RemovesLabel(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortConflicts(conflicts map[string][]LabelConflict) {
+	for name := range conflicts {
+		slices.SortFunc(conflicts[name], func(a, b LabelConflict) bool {
+			return a.Key < b.Key
+		})
+	}
+}
+
+func (nls *NamespaceLabelList) GetLabels() map[string]string {
+	labelsToAdd, _ := nls.ResolveLabels()
 	return labelsToAdd
 }
 
 func (nls *NamespaceLabelList) GetLabelsExcept(nlToIgnore *NamespaceLabel) map[string]string {
+	return nls.except(nlToIgnore).GetLabels()
+}
+
+func (nls *NamespaceLabelList) GetAnnotations() map[string]string {
+	annotationsToAdd, _ := nls.ResolveAnnotations()
+	return annotationsToAdd
+}
+
+func (nls *NamespaceLabelList) GetAnnotationsExcept(nlToIgnore *NamespaceLabel) map[string]string {
+	return nls.except(nlToIgnore).GetAnnotations()
+}
+
+// WithoutLabels returns a copy of the list without the given labels, keyed by the NamespaceLabel name and the label key
+func (nls *NamespaceLabelList) WithoutLabels(labelsToDrop map[string]map[string]string) *NamespaceLabelList {
+	namespaceLabels := nls.DeepCopy()
+	for i := range namespaceLabels.Items {
+		for key := range labelsToDrop[namespaceLabels.Items[i].Name] {
+			delete(namespaceLabels.Items[i].Spec.Labels, key)
+		}
+	}
+	return namespaceLabels
+}
+
+func (nls *NamespaceLabelList) except(nlToIgnore *NamespaceLabel) *NamespaceLabelList {
 	var namespaceLabels = NamespaceLabelList{}
 	for _, nl := range nls.Items {
 		if nl.Name == nlToIgnore.Name {
@@ -82,7 +639,7 @@ func (nls *NamespaceLabelList) GetLabelsExcept(nlToIgnore *NamespaceLabel) map[s
 		}
 		namespaceLabels.Items = append(namespaceLabels.Items, nl)
 	}
-	return namespaceLabels.GetLabels()
+	return &namespaceLabels
 }
 
 func init() {
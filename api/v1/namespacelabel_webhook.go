@@ -0,0 +1,414 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"idandaniel.io/namespacelabel-demo/common/protected"
+)
+
+// log is for logging in this package.
+var namespacelabellog = logf.Log.WithName("namespacelabel-resource")
+
+//+kubebuilder:webhook:path=/validate-idandaniel-idandaniel-io-v1-namespacelabel,mutating=false,failurePolicy=fail,sideEffects=None,groups=idandaniel.idandaniel.io,resources=namespacelabels,verbs=create;update,versions=v1,name=vnamespacelabel.kb.io,admissionReviewVersions=v1
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// NamespaceLabelValidator rejects NamespaceLabels the controller can not apply to their Namespace. A key another
+// NamespaceLabel of the Namespace sets to a different value is only rejected when both refuse the conflict, the
+// conflicts resolved by the conflict policies are accepted.
+type NamespaceLabelValidator struct {
+	// Client reads the Namespace, its other NamespaceLabels and the NamespaceLabelPolicies, and reviews the access of the
+	// requesting user to the label sources in other Namespaces
+	Client client.Client
+	// ProtectedLabels are the labels NamespaceLabels may never change, defaults to the Kubernetes labels
+	ProtectedLabels *protected.Rules
+	// ProtectedAnnotations are the annotations NamespaceLabels may never change, defaults to the kubectl annotations
+	ProtectedAnnotations *protected.Rules
+}
+
+var _ admission.CustomValidator = &NamespaceLabelValidator{}
+
+// SetupWebhookWithManager registers the validating webhook of NamespaceLabels with the Manager.
+func (v *NamespaceLabelValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&NamespaceLabel{}).
+		WithValidator(v).
+		Complete()
+}
+
+// ValidateCreate implements admission.CustomValidator so a webhook will be registered for the type
+func (v *NamespaceLabelValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	nl, ok := obj.(*NamespaceLabel)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a NamespaceLabel but got a %T", obj))
+	}
+	namespacelabellog.Info("validate create", "name", nl.Name, "namespace", nl.Namespace)
+
+	return v.validate(ctx, nl)
+}
+
+// ValidateUpdate implements admission.CustomValidator so a webhook will be registered for the type
+func (v *NamespaceLabelValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldNl, ok := oldObj.(*NamespaceLabel)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a NamespaceLabel but got a %T", oldObj))
+	}
+	nl, ok := newObj.(*NamespaceLabel)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a NamespaceLabel but got a %T", newObj))
+	}
+	namespacelabellog.Info("validate update", "name", nl.Name, "namespace", nl.Namespace)
+
+	// Finalizer and metadata updates, including the ones releasing a NamespaceLabel being deleted, are always allowed
+	if nl.IsBeingDeleted() || equality.Semantic.DeepEqual(oldNl.Spec, nl.Spec) {
+		return nil
+	}
+
+	return v.validate(ctx, nl)
+}
+
+// ValidateDelete implements admission.CustomValidator so a webhook will be registered for the type
+func (v *NamespaceLabelValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func (v *NamespaceLabelValidator) protectedLabels() *protected.Rules {
+	if v.ProtectedLabels == nil {
+		return protected.DefaultRules()
+	}
+	return v.ProtectedLabels
+}
+
+func (v *NamespaceLabelValidator) protectedAnnotations() *protected.Rules {
+	if v.ProtectedAnnotations == nil {
+		return protected.DefaultAnnotationRules()
+	}
+	return v.ProtectedAnnotations
+}
+
+// Validates the syntax, the protection, the policies and the conflicts of the labels and annotations of the NamespaceLabel
+func (v *NamespaceLabelValidator) validate(ctx context.Context, nl *NamespaceLabel) error {
+	labelsPath := field.NewPath("spec", "labels")
+	annotationsPath := field.NewPath("spec", "annotations")
+
+	var errs field.ErrorList
+	errs = append(errs, validateLabels(nl.Spec.Labels, nl.Spec.Templated, labelsPath, v.protectedLabels())...)
+	errs = append(errs, validateApplyPolicies(nl.Spec.ApplyPolicies, nl.Spec.Labels, field.NewPath("spec", "applyPolicies"))...)
+	errs = append(errs, validateRemoveLabels(nl.Spec.RemoveLabels, nl.Spec.Labels, field.NewPath("spec", "removeLabels"), v.protectedLabels())...)
+	errs = append(errs, validateResyncInterval(nl.Spec.ResyncInterval, field.NewPath("spec", "resyncInterval"))...)
+	errs = append(errs, validateAnnotations(nl.Spec.Annotations, annotationsPath, v.protectedAnnotations())...)
+
+	sourceErrs, err := v.validateSources(ctx, nl, field.NewPath("spec", "labelsFrom"))
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	errs = append(errs, sourceErrs...)
+
+	namespace := &corev1.Namespace{}
+	if err := v.Client.Get(ctx, types.NamespacedName{Name: nl.Namespace}, namespace); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return apierrors.NewInternalError(fmt.Errorf("failed to get namespace %s: %w", nl.Namespace, err))
+		}
+		namespace = nil
+	}
+
+	policyErrs, err := v.validatePolicies(ctx, nl, namespace, labelsPath)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	errs = append(errs, policyErrs...)
+
+	namespaceLabels := &NamespaceLabelList{}
+	if err := v.Client.List(ctx, namespaceLabels, client.InNamespace(nl.Namespace)); err != nil {
+		return apierrors.NewInternalError(fmt.Errorf("failed to list the NamespaceLabels of namespace %s: %w", nl.Namespace, err))
+	}
+	contenders := &NamespaceLabelList{Items: []NamespaceLabel{*withRenderedLabels(nl, namespace)}}
+	for _, other := range namespaceLabels.sortedItems() {
+		if other.Name != nl.Name && !other.IsBeingDeleted() {
+			contenders.Items = append(contenders.Items, *withRenderedLabels(other, namespace))
+		}
+	}
+	errs = append(errs, validateConflicts(nl.Name, contenders, (*NamespaceLabelList).ResolveLabels, (*NamespaceLabel).AppliedLabels, labelsPath)...)
+	errs = append(errs, validateConflicts(nl.Name, contenders, (*NamespaceLabelList).ResolveAnnotations, func(namespaceLabel *NamespaceLabel) map[string]string {
+		return namespaceLabel.Spec.Annotations
+	}, annotationsPath)...)
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("NamespaceLabel").GroupKind(), nl.Name, errs)
+}
+
+// Checks the labels against the NamespaceLabelPolicies selecting the Namespace of the NamespaceLabel, nil when the
+// Namespace does not exist
+func (v *NamespaceLabelValidator) validatePolicies(ctx context.Context, nl *NamespaceLabel, namespace *corev1.Namespace, fldPath *field.Path) (field.ErrorList, error) {
+	if namespace == nil {
+		return nil, nil
+	}
+	policies := &NamespaceLabelPolicyList{}
+	if err := v.Client.List(ctx, policies); err != nil {
+		return nil, fmt.Errorf("failed to list the NamespaceLabelPolicies: %w", err)
+	}
+
+	labels := renderedLabels(nl, namespace)
+	var errs field.ErrorList
+	for _, key := range sortedKeys(labels) {
+		if message := policies.Check(namespace, key, labels[key]); message != "" {
+			errs = append(errs, field.Forbidden(fldPath.Key(key), message))
+		}
+	}
+	return errs, nil
+}
+
+// Get the labels the NamespaceLabel applies with the values they render to against the Namespace. The labels failing
+// to render are reported in the status instead, and RemoveOnly labels are never applied. Without the Namespace the
+// templates are kept as they are.
+func renderedLabels(nl *NamespaceLabel, namespace *corev1.Namespace) map[string]string {
+	applied := nl.AppliedLabels()
+	if namespace == nil {
+		return applied
+	}
+
+	labels, failed := nl.RenderLabels(namespace)
+	rendered := make(map[string]string, len(applied))
+	for key := range applied {
+		if _, isFailed := failed[key]; !isFailed {
+			rendered[key] = labels[key]
+		}
+	}
+	return rendered
+}
+
+// Get a copy of the NamespaceLabel setting its rendered labels, as the controller resolves them
+func withRenderedLabels(nl *NamespaceLabel, namespace *corev1.Namespace) *NamespaceLabel {
+	rendered := nl.DeepCopy()
+	rendered.Spec.Labels = renderedLabels(nl, namespace)
+	rendered.Spec.Templated = false
+	return rendered
+}
+
+// Checks every label source references a ConfigMap, which the requesting user may get when it is in another Namespace.
+// Whether the ConfigMap is shared with the Namespace is checked by the controller.
+func (v *NamespaceLabelValidator) validateSources(ctx context.Context, nl *NamespaceLabel, fldPath *field.Path) (field.ErrorList, error) {
+	var errs field.ErrorList
+	for i, source := range nl.Spec.LabelsFrom {
+		refPath := fldPath.Index(i).Child("configMapRef")
+		if source.ConfigMapRef == nil {
+			errs = append(errs, field.Required(refPath, "a ConfigMap must be referenced"))
+			continue
+		}
+
+		reference := source.Reference(nl.Namespace)
+		if reference.Namespace == nl.Namespace {
+			continue
+		}
+		allowed, err := v.canGet(ctx, reference)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			errs = append(errs, field.Forbidden(refPath.Child("namespace"), fmt.Sprintf(
+				"referencing a ConfigMap of another Namespace requires the permission to get ConfigMap %s in Namespace %s",
+				reference.Name, reference.Namespace,
+			)))
+		}
+	}
+	return errs, nil
+}
+
+// Reviews whether the user making the admission request may get the ConfigMap
+func (v *NamespaceLabelValidator) canGet(ctx context.Context, reference types.NamespacedName) (bool, error) {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	extra := make(map[string]authorizationv1.ExtraValue, len(req.UserInfo.Extra))
+	for key, value := range req.UserInfo.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: reference.Namespace,
+				Verb:      "get",
+				Resource:  "configmaps",
+				Name:      reference.Name,
+			},
+			User:   req.UserInfo.Username,
+			Groups: req.UserInfo.Groups,
+			UID:    req.UserInfo.UID,
+			Extra:  extra,
+		},
+	}
+	if err := v.Client.Create(ctx, review); err != nil {
+		return false, fmt.Errorf("failed to review the access to ConfigMap %s: %w", reference, err)
+	}
+	return review.Status.Allowed, nil
+}
+
+// Templated label values are only parsed, their rendered values are validated by the controller
+func validateLabels(labels map[string]string, templated bool, fldPath *field.Path, rules *protected.Rules) field.ErrorList {
+	var errs field.ErrorList
+	for _, key := range sortedKeys(labels) {
+		keyErrs := metav1validation.ValidateLabelName(key, fldPath)
+		errs = append(errs, keyErrs...)
+		if templated {
+			if _, err := ParseLabelTemplate(labels[key]); err != nil {
+				errs = append(errs, field.Invalid(fldPath.Key(key), labels[key], err.Error()))
+			}
+		} else {
+			for _, msg := range validation.IsValidLabelValue(labels[key]) {
+				errs = append(errs, field.Invalid(fldPath.Key(key), labels[key], msg))
+			}
+		}
+		if len(keyErrs) == 0 && rules.IsProtected(key) {
+			errs = append(errs, field.Forbidden(fldPath.Key(key), "the label is protected and can not be set by NamespaceLabels"))
+		}
+	}
+	return errs
+}
+
+// Every apply policy must be a known policy of one of the labels
+func validateApplyPolicies(policies map[string]ApplyPolicy, labels map[string]string, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	keys := maps.Keys(policies)
+	slices.Sort(keys)
+	for _, key := range keys {
+		switch policies[key] {
+		case ApplyEnforce, ApplyIfAbsent, RemoveOnly:
+		default:
+			errs = append(errs, field.NotSupported(fldPath.Key(key), policies[key], []string{string(ApplyEnforce), string(ApplyIfAbsent), string(RemoveOnly)}))
+		}
+		if _, exists := labels[key]; !exists {
+			errs = append(errs, field.Invalid(fldPath.Key(key), policies[key], "the label is not set in spec.labels"))
+		}
+	}
+	return errs
+}
+
+// Every removed label must be a label key or a key prefix ending with *, which does not match the labels the
+// NamespaceLabel sets. Protected keys can not be removed.
+func validateRemoveLabels(removeLabels []string, labels map[string]string, fldPath *field.Path, rules *protected.Rules) field.ErrorList {
+	var errs field.ErrorList
+	for i, removed := range removeLabels {
+		key := removed
+		if strings.HasSuffix(removed, "*") {
+			// Any key starting with the prefix is a valid label key when the prefix followed by a character is
+			key = strings.TrimSuffix(removed, "*") + "x"
+			if removed == "*" {
+				errs = append(errs, field.Invalid(fldPath.Index(i), removed, "the prefix must not be empty"))
+				continue
+			}
+		}
+		if keyErrs := metav1validation.ValidateLabelName(key, fldPath.Index(i)); len(keyErrs) > 0 {
+			errs = append(errs, field.Invalid(fldPath.Index(i), removed, "must be a label key or a label key prefix ending with *"))
+			continue
+		}
+		if removed == key && rules.IsProtected(key) {
+			errs = append(errs, field.Forbidden(fldPath.Index(i), "the label is protected and can not be removed by NamespaceLabels"))
+		}
+		for _, labelKey := range sortedKeys(labels) {
+			if MatchesRemoval(removed, labelKey) {
+				errs = append(errs, field.Invalid(fldPath.Index(i), removed, fmt.Sprintf("matches the label %s set in spec.labels", labelKey)))
+			}
+		}
+	}
+	return errs
+}
+
+// The resync interval must not be shorter than MinResyncInterval
+func validateResyncInterval(interval *metav1.Duration, fldPath *field.Path) field.ErrorList {
+	if interval == nil || interval.Duration >= MinResyncInterval {
+		return nil
+	}
+	return field.ErrorList{field.Invalid(fldPath, interval.Duration.String(), fmt.Sprintf("must be at least %s", MinResyncInterval))}
+}
+
+func validateAnnotations(annotations map[string]string, fldPath *field.Path, rules *protected.Rules) field.ErrorList {
+	var errs field.ErrorList
+	for _, key := range sortedKeys(annotations) {
+		msgs := validation.IsQualifiedName(strings.ToLower(key))
+		for _, msg := range msgs {
+			errs = append(errs, field.Invalid(fldPath.Key(key), key, msg))
+		}
+		if len(msgs) == 0 && rules.IsProtected(key) {
+			errs = append(errs, field.Forbidden(fldPath.Key(key), "the annotation is protected and can not be set by NamespaceLabels"))
+		}
+	}
+	if err := apivalidation.ValidateAnnotationsSize(annotations); err != nil {
+		errs = append(errs, field.TooLong(fldPath, "", apivalidation.TotalAnnotationSizeLimitB))
+	}
+	return errs
+}
+
+// Checks the keys the NamespaceLabel sets which the controller would apply from none of the contenders: every
+// NamespaceLabel setting such a key to a different value refuses the conflict. The other conflicts are resolved by the
+// conflict policies, by priority or by age.
+func validateConflicts(
+	name string,
+	contenders *NamespaceLabelList,
+	resolve func(*NamespaceLabelList) (map[string]string, map[string][]LabelConflict),
+	valuesOf func(*NamespaceLabel) map[string]string,
+	fldPath *field.Path,
+) field.ErrorList {
+	_, conflicts := resolve(contenders)
+	var errs field.ErrorList
+	for _, conflict := range conflicts[name] {
+		if conflict.Winner != "" {
+			continue
+		}
+		for _, other := range contenders.sortedItems() {
+			otherValue, exists := valuesOf(other)[conflict.Key]
+			if other.Name == name || !exists || otherValue == conflict.Value {
+				continue
+			}
+			errs = append(errs, field.Forbidden(fldPath.Key(conflict.Key), fmt.Sprintf(
+				"conflicts with NamespaceLabel %s setting it to %q, both refuse the conflict so neither applies it, use another conflict policy to resolve it",
+				other.Name, otherValue,
+			)))
+		}
+	}
+	return errs
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := maps.Keys(m)
+	slices.Sort(keys)
+	return keys
+}
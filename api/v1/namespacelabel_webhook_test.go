@@ -0,0 +1,300 @@
+package v1
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var _ = Describe("NamespaceLabel Webhook", func() {
+
+	const Namespace = "tenant"
+
+	ctx := context.Background()
+
+	newNamespaceLabel := func(name string, labels map[string]string) *NamespaceLabel {
+		return &NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: Namespace},
+			Spec:       NamespaceLabelSpec{Labels: labels},
+		}
+	}
+
+	newValidator := func(existing ...client.Object) *NamespaceLabelValidator {
+		scheme := runtime.NewScheme()
+		Expect(AddToScheme(scheme)).To(Succeed())
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: Namespace, Labels: map[string]string{"tenant": "true"}}}
+		return &NamespaceLabelValidator{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(namespace).WithObjects(existing...).Build()}
+	}
+
+	It("Should accept valid labels and annotations", func() {
+		nl := newNamespaceLabel("valid", map[string]string{"team": "payments", "example.com/tier": "gold"})
+		nl.Spec.Annotations = map[string]string{"scheduler.alpha.kubernetes.io/node-selector": "env=prod"}
+
+		Expect(newValidator().ValidateCreate(ctx, nl)).To(Succeed())
+	})
+
+	It("Should reject invalid label syntax", func() {
+		nl := newNamespaceLabel("invalid", map[string]string{
+			"not a key": "value",
+			"team":      strings.Repeat("a", 64),
+		})
+		nl.Spec.Annotations = map[string]string{"not an annotation": "value"}
+
+		err := newValidator().ValidateCreate(ctx, nl)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`spec.labels: Invalid value: "not a key"`))
+		Expect(err.Error()).To(ContainSubstring("spec.labels[team]: Invalid value"))
+		Expect(err.Error()).To(ContainSubstring("must be no more than 63 characters"))
+		Expect(err.Error()).To(ContainSubstring(`spec.annotations[not an annotation]: Invalid value: "not an annotation"`))
+	})
+
+	It("Should reject protected keys", func() {
+		nl := newNamespaceLabel("protected", map[string]string{"app.kubernetes.io/name": "payments"})
+		nl.Spec.Annotations = map[string]string{"kubectl.kubernetes.io/last-applied-configuration": "{}"}
+
+		err := newValidator().ValidateCreate(ctx, nl)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.labels[app.kubernetes.io/name]: Forbidden: the label is protected"))
+		Expect(err.Error()).To(ContainSubstring("spec.annotations[kubectl.kubernetes.io/last-applied-configuration]: Forbidden: the annotation is protected"))
+	})
+
+	It("Should reject labels denied by NamespaceLabelPolicies selecting the Namespace", func() {
+		policy := &NamespaceLabelPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenants"},
+			Spec: NamespaceLabelPolicySpec{
+				NamespaceSelector: NamespaceSelector{LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "true"}}},
+				Deny:              []LabelPolicyRule{{Keys: []string{"tier"}, ValuePatterns: []string{"prod"}}},
+			},
+		}
+		unselected := policy.DeepCopy()
+		unselected.Name = "others"
+		unselected.Spec.NamespaceSelector = NamespaceSelector{Names: []string{"other"}}
+		unselected.Spec.Deny = []LabelPolicyRule{{}}
+
+		err := newValidator(policy, unselected).ValidateCreate(ctx, newNamespaceLabel("denied", map[string]string{"tier": "prod"}))
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.labels[tier]: Forbidden: Denied by NamespaceLabelPolicy tenants: the label is in the deny list"))
+
+		Expect(newValidator(policy, unselected).ValidateCreate(ctx, newNamespaceLabel("allowed", map[string]string{"tier": "dev"}))).To(Succeed())
+	})
+
+	It("Should only parse the values of templated NamespaceLabels", func() {
+		nl := newNamespaceLabel("templated", map[string]string{
+			"owner": "{{ .Namespace.Annotations.owner }}",
+			"env":   `{{ trimPrefix "team-" .Namespace.Name }}`,
+		})
+		nl.Spec.Templated = true
+		Expect(newValidator().ValidateCreate(ctx, nl)).To(Succeed())
+
+		nl.Spec.Labels["broken"] = "{{ .Namespace.Name"
+		err := newValidator().ValidateCreate(ctx, nl)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.labels[broken]: Invalid value"))
+		Expect(err.Error()).NotTo(ContainSubstring("spec.labels[owner]"))
+	})
+
+	It("Should check the rendered values of templated labels against the NamespaceLabelPolicies", func() {
+		policy := &NamespaceLabelPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenants"},
+			Spec: NamespaceLabelPolicySpec{
+				Deny: []LabelPolicyRule{{Keys: []string{"tenant"}, ValuePatterns: []string{"true"}}},
+			},
+		}
+		nl := newNamespaceLabel("templated", map[string]string{"tenant": "{{ .Namespace.Labels.tenant }}"})
+		nl.Spec.Templated = true
+
+		err := newValidator(policy).ValidateCreate(ctx, nl)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.labels[tenant]: Forbidden: Denied by NamespaceLabelPolicy tenants"))
+	})
+
+	It("Should reject apply policies of unknown labels", func() {
+		nl := newNamespaceLabel("policies", map[string]string{"team": "payments", "legacy": ""})
+		nl.Spec.ApplyPolicies = map[string]ApplyPolicy{"team": ApplyIfAbsent, "legacy": RemoveOnly}
+		Expect(newValidator().ValidateCreate(ctx, nl)).To(Succeed())
+
+		nl.Spec.ApplyPolicies["tier"] = ApplyEnforce
+		nl.Spec.ApplyPolicies["legacy"] = "Never"
+		err := newValidator().ValidateCreate(ctx, nl)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.applyPolicies[tier]: Invalid value: \"Enforce\": the label is not set in spec.labels"))
+		Expect(err.Error()).To(ContainSubstring("spec.applyPolicies[legacy]: Unsupported value: \"Never\""))
+	})
+
+	It("Should reject removed labels which are not label keys or prefixes", func() {
+		nl := newNamespaceLabel("removals", map[string]string{"team": "payments"})
+		nl.Spec.RemoveLabels = []string{"team-old", "legacy.example.com/*"}
+		Expect(newValidator().ValidateCreate(ctx, nl)).To(Succeed())
+
+		nl.Spec.RemoveLabels = []string{"*", "not a key", "team*", "kubernetes.io/metadata.name"}
+		err := newValidator().ValidateCreate(ctx, nl)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.removeLabels[0]: Invalid value: \"*\": the prefix must not be empty"))
+		Expect(err.Error()).To(ContainSubstring("spec.removeLabels[1]: Invalid value: \"not a key\""))
+		Expect(err.Error()).To(ContainSubstring("spec.removeLabels[2]: Invalid value: \"team*\": matches the label team set in spec.labels"))
+		Expect(err.Error()).To(ContainSubstring("spec.removeLabels[3]: Forbidden: the label is protected"))
+	})
+
+	It("Should reject resync intervals shorter than a minute", func() {
+		nl := newNamespaceLabel("resync", map[string]string{"team": "payments"})
+		nl.Spec.ResyncInterval = &metav1.Duration{Duration: 5 * time.Minute}
+		Expect(newValidator().ValidateCreate(ctx, nl)).To(Succeed())
+
+		nl.Spec.ResyncInterval = &metav1.Duration{Duration: 10 * time.Second}
+		err := newValidator().ValidateCreate(ctx, nl)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.resyncInterval: Invalid value: \"10s\": must be at least 1m0s"))
+	})
+
+	It("Should reject label sources without a ConfigMap", func() {
+		nl := newNamespaceLabel("sources", nil)
+		nl.Spec.LabelsFrom = []LabelsSource{
+			{ConfigMapRef: &SourceReference{Name: "costs"}},
+			{Prefix: "cost/"},
+		}
+
+		err := newValidator().ValidateCreate(ctx, nl)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).NotTo(ContainSubstring("spec.labelsFrom[0]"))
+		Expect(err.Error()).To(ContainSubstring("spec.labelsFrom[1].configMapRef: Required value"))
+	})
+
+	It("Should reject label sources of other Namespaces the user may not get", func() {
+		nl := newNamespaceLabel("sources", nil)
+		nl.Spec.LabelsFrom = []LabelsSource{{ConfigMapRef: &SourceReference{Name: "costs", Namespace: "finance"}}}
+		requestBy := func(username string) context.Context {
+			return admission.NewContextWithRequest(ctx, admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				UserInfo: authenticationv1.UserInfo{Username: username},
+			}})
+		}
+		validator := newValidator()
+		validator.Client = &reviewingClient{Client: validator.Client, allowed: []string{"admin"}}
+
+		err := validator.ValidateCreate(requestBy("tenant"), nl)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.labelsFrom[0].configMapRef.namespace: Forbidden"))
+
+		Expect(validator.ValidateCreate(requestBy("admin"), nl)).To(Succeed())
+	})
+
+	It("Should reject keys conflicting with another NamespaceLabel when both refuse the conflict", func() {
+		existing := newNamespaceLabel("existing", map[string]string{"team": "payments", "tier": "gold"})
+		existing.Spec.ConflictPolicy = Refuse
+		nl := newNamespaceLabel("conflicting", map[string]string{"team": "search", "tier": "gold"})
+		nl.Spec.ConflictPolicy = Refuse
+
+		err := newValidator(existing).ValidateCreate(ctx, nl)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`spec.labels[team]: Forbidden: conflicts with NamespaceLabel existing setting it to "payments"`))
+		Expect(err.Error()).NotTo(ContainSubstring("spec.labels[tier]"))
+	})
+
+	It("Should accept conflicts resolved by the conflict policies", func() {
+		existing := newNamespaceLabel("existing", map[string]string{"team": "payments"})
+		Expect(newValidator(existing).ValidateCreate(ctx, newNamespaceLabel("newer", map[string]string{"team": "search"}))).To(Succeed())
+
+		existing.Spec.ConflictPolicy = HighestPriority
+		existing.Spec.Priority = 1
+		prioritized := newNamespaceLabel("prioritized", map[string]string{"team": "search"})
+		prioritized.Spec.ConflictPolicy = HighestPriority
+		prioritized.Spec.Priority = 2
+		Expect(newValidator(existing).ValidateCreate(ctx, prioritized)).To(Succeed())
+
+		// Equal priorities fall back to the creation time
+		prioritized.Spec.Priority = 1
+		Expect(newValidator(existing).ValidateCreate(ctx, prioritized)).To(Succeed())
+
+		refusing := newNamespaceLabel("refusing", map[string]string{"team": "search"})
+		refusing.Spec.ConflictPolicy = Refuse
+		Expect(newValidator(existing).ValidateCreate(ctx, refusing)).To(Succeed())
+
+		// A third NamespaceLabel wins the key both refusing NamespaceLabels set
+		existing.Spec.ConflictPolicy = Refuse
+		winner := newNamespaceLabel("winner", map[string]string{"team": "platform"})
+		Expect(newValidator(existing, winner).ValidateCreate(ctx, refusing)).To(Succeed())
+		Expect(newValidator(existing).ValidateCreate(ctx, refusing)).NotTo(Succeed())
+	})
+
+	It("Should compare the rendered values of templated NamespaceLabels", func() {
+		existing := newNamespaceLabel("existing", map[string]string{"team": "{{ .Namespace.Name }}"})
+		existing.Spec.Templated = true
+		existing.Spec.ConflictPolicy = Refuse
+
+		nl := newNamespaceLabel("same", map[string]string{"team": "tenant"})
+		nl.Spec.ConflictPolicy = Refuse
+		Expect(newValidator(existing).ValidateCreate(ctx, nl)).To(Succeed())
+
+		nl.Spec.Labels["team"] = "{{ .Namespace.Labels.tenant }}"
+		nl.Spec.Templated = true
+		err := newValidator(existing).ValidateCreate(ctx, nl)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`spec.labels[team]: Forbidden: conflicts with NamespaceLabel existing setting it to "tenant"`))
+	})
+
+	It("Should leave RemoveOnly labels out of the conflicts and the NamespaceLabelPolicies", func() {
+		policy := &NamespaceLabelPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenants"},
+			Spec:       NamespaceLabelPolicySpec{Deny: []LabelPolicyRule{{Keys: []string{"legacy"}}}},
+		}
+		existing := newNamespaceLabel("existing", map[string]string{"team": "payments", "legacy": ""})
+		existing.Spec.ApplyPolicies = map[string]ApplyPolicy{"legacy": RemoveOnly}
+		nl := newNamespaceLabel("removing", map[string]string{"team": "", "legacy": "true"})
+		nl.Spec.ApplyPolicies = map[string]ApplyPolicy{"team": RemoveOnly}
+
+		err := newValidator(policy, existing).ValidateCreate(ctx, nl)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.labels[legacy]: Forbidden: Denied by NamespaceLabelPolicy tenants"))
+		Expect(err.Error()).NotTo(ContainSubstring("conflicts with"))
+		Expect(err.Error()).NotTo(ContainSubstring("spec.labels[team]"))
+
+		nl.Spec.ApplyPolicies["legacy"] = RemoveOnly
+		Expect(newValidator(policy, existing).ValidateCreate(ctx, nl)).To(Succeed())
+	})
+
+	It("Should not conflict with itself on update", func() {
+		existing := newNamespaceLabel("existing", map[string]string{"team": "payments"})
+		updated := existing.DeepCopy()
+		updated.Spec.Labels["team"] = "search"
+
+		Expect(newValidator(existing).ValidateUpdate(ctx, existing, updated)).To(Succeed())
+	})
+
+	It("Should allow updates which do not change the spec", func() {
+		invalid := newNamespaceLabel("invalid", map[string]string{"app.kubernetes.io/name": "payments"})
+		updated := invalid.DeepCopy()
+		updated.Finalizers = []string{"idandaniel.idandaniel.io/finalizer"}
+
+		Expect(newValidator().ValidateUpdate(ctx, invalid, updated)).To(Succeed())
+		Expect(newValidator().ValidateDelete(ctx, invalid)).To(Succeed())
+	})
+})
+
+// reviewingClient allows the subject access reviews of the allowed users, which the fake client can not review
+type reviewingClient struct {
+	client.Client
+	allowed []string
+}
+
+func (c *reviewingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if review, ok := obj.(*authorizationv1.SubjectAccessReview); ok {
+		for _, user := range c.allowed {
+			review.Status.Allowed = review.Status.Allowed || review.Spec.User == user
+		}
+		return nil
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/lru"
+
+	"idandaniel.io/namespacelabel-demo/common/protected"
+)
+
+// LabelPolicyRule matches labels by their key and value
+type LabelPolicyRule struct {
+	// Keys are exact label keys
+	// +optional
+	Keys []string `json:"keys,omitempty"`
+
+	// Prefixes are DNS prefixes, a prefix matches its own keys and the keys of its subdomains
+	// +optional
+	Prefixes []string `json:"prefixes,omitempty"`
+
+	// ValuePatterns are regular expressions matched against the whole label value.
+	// A rule without value patterns matches any value, a rule without keys and prefixes matches any key.
+	// +optional
+	ValuePatterns []string `json:"valuePatterns,omitempty"`
+}
+
+// valuePatternCacheSize is the number of compiled value patterns kept in the cache
+const valuePatternCacheSize = 1024
+
+// valuePatterns caches the compiled value patterns by their source, so the patterns in use are compiled once
+var valuePatterns = lru.New(valuePatternCacheSize)
+
+// CompileValuePattern compiles a value pattern matching the whole label value. Invalid patterns are not cached.
+func CompileValuePattern(pattern string) (*regexp.Regexp, error) {
+	if cached, exists := valuePatterns.Get(pattern); exists {
+		return cached.(*regexp.Regexp), nil
+	}
+
+	compiled, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid value pattern %q: %w", pattern, err)
+	}
+	valuePatterns.Add(pattern, compiled)
+	return compiled, nil
+}
+
+// Matches returns true when the rule matches the label, failing on invalid value patterns
+func (rule *LabelPolicyRule) Matches(key string, value string) (bool, error) {
+	if len(rule.Keys) > 0 || len(rule.Prefixes) > 0 {
+		keys := &protected.Rules{Keys: rule.Keys, Prefixes: rule.Prefixes}
+		if !keys.IsProtected(key) {
+			return false, nil
+		}
+	}
+
+	if len(rule.ValuePatterns) == 0 {
+		return true, nil
+	}
+	for _, pattern := range rule.ValuePatterns {
+		compiled, err := CompileValuePattern(pattern)
+		if err != nil {
+			return false, err
+		}
+		if compiled.MatchString(value) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// NamespaceLabelPolicySpec defines the desired state of NamespaceLabelPolicy
+type NamespaceLabelPolicySpec struct {
+	// NamespaceSelector selects the Namespaces whose NamespaceLabels the policy restricts
+	NamespaceSelector NamespaceSelector `json:"namespaceSelector"`
+
+	// Allow lists the labels NamespaceLabels may set, every label is allowed when empty
+	// +optional
+	Allow []LabelPolicyRule `json:"allow,omitempty"`
+
+	// Deny lists the labels NamespaceLabels may never set, it takes precedence over Allow
+	// +optional
+	Deny []LabelPolicyRule `json:"deny,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// NamespaceLabelPolicy is the Schema for the namespacelabelpolicies API
+type NamespaceLabelPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NamespaceLabelPolicySpec `json:"spec,omitempty"`
+}
+
+// Denies returns the reason the policy denies the label, or an empty string when it is allowed.
+// Invalid value patterns deny every label, so a broken policy never lets labels through.
+func (p *NamespaceLabelPolicy) Denies(key string, value string) string {
+	for _, rule := range p.Spec.Deny {
+		matches, err := rule.Matches(key, value)
+		if err != nil {
+			return err.Error()
+		}
+		if matches {
+			return "the label is in the deny list"
+		}
+	}
+
+	if len(p.Spec.Allow) == 0 {
+		return ""
+	}
+	for _, rule := range p.Spec.Allow {
+		matches, err := rule.Matches(key, value)
+		if err != nil {
+			return err.Error()
+		}
+		if matches {
+			return ""
+		}
+	}
+	return "the label is not in the allow list"
+}
+
+//+kubebuilder:object:root=true
+
+// NamespaceLabelPolicyList contains a list of NamespaceLabelPolicy
+type NamespaceLabelPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NamespaceLabelPolicy `json:"items"`
+}
+
+// Check returns a message explaining why the policies selecting the Namespace deny the label,
+// or an empty string when all of them allow it. Policies with invalid selectors are ignored.
+func (nlps *NamespaceLabelPolicyList) Check(namespace *corev1.Namespace, key string, value string) string {
+	for i := range nlps.Items {
+		policy := &nlps.Items[i]
+		if matches, err := policy.Spec.NamespaceSelector.Matches(namespace); err != nil || !matches {
+			continue
+		}
+		if reason := policy.Denies(key, value); reason != "" {
+			return fmt.Sprintf("Denied by NamespaceLabelPolicy %s: %s", policy.Name, reason)
+		}
+	}
+	return ""
+}
+
+// DeniedLabels checks every applied label of the NamespaceLabels against the policies selecting the Namespace.
+// It returns the messages of the denied labels keyed by the NamespaceLabel name and the label key.
+func (nlps *NamespaceLabelPolicyList) DeniedLabels(namespace *corev1.Namespace, namespaceLabels *NamespaceLabelList) map[string]map[string]string {
+	denied := make(map[string]map[string]string)
+	for _, nl := range namespaceLabels.Items {
+		for key, value := range nl.AppliedLabels() {
+			message := nlps.Check(namespace, key, value)
+			if message == "" {
+				continue
+			}
+			if denied[nl.Name] == nil {
+				denied[nl.Name] = make(map[string]string)
+			}
+			denied[nl.Name][key] = message
+		}
+	}
+	return denied
+}
+
+func init() {
+	SchemeBuilder.Register(&NamespaceLabelPolicy{}, &NamespaceLabelPolicyList{})
+}
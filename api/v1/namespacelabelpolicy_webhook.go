@@ -0,0 +1,114 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// log is for logging in this package.
+var namespacelabelpolicylog = logf.Log.WithName("namespacelabelpolicy-resource")
+
+//+kubebuilder:webhook:path=/validate-idandaniel-idandaniel-io-v1-namespacelabelpolicy,mutating=false,failurePolicy=fail,sideEffects=None,groups=idandaniel.idandaniel.io,resources=namespacelabelpolicies,verbs=create;update,versions=v1,name=vnamespacelabelpolicy.kb.io,admissionReviewVersions=v1
+
+// NamespaceLabelPolicyValidator rejects NamespaceLabelPolicies with selectors or value patterns which do not compile
+type NamespaceLabelPolicyValidator struct{}
+
+var _ admission.CustomValidator = &NamespaceLabelPolicyValidator{}
+
+// SetupWebhookWithManager registers the validating webhook of NamespaceLabelPolicies with the Manager.
+func (v *NamespaceLabelPolicyValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&NamespaceLabelPolicy{}).
+		WithValidator(v).
+		Complete()
+}
+
+// ValidateCreate implements admission.CustomValidator so a webhook will be registered for the type
+func (v *NamespaceLabelPolicyValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	policy, ok := obj.(*NamespaceLabelPolicy)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a NamespaceLabelPolicy but got a %T", obj))
+	}
+	namespacelabelpolicylog.Info("validate create", "name", policy.Name)
+
+	return validatePolicy(policy)
+}
+
+// ValidateUpdate implements admission.CustomValidator so a webhook will be registered for the type
+func (v *NamespaceLabelPolicyValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	policy, ok := newObj.(*NamespaceLabelPolicy)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a NamespaceLabelPolicy but got a %T", newObj))
+	}
+	namespacelabelpolicylog.Info("validate update", "name", policy.Name)
+
+	return validatePolicy(policy)
+}
+
+// ValidateDelete implements admission.CustomValidator so a webhook will be registered for the type
+func (v *NamespaceLabelPolicyValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+// Validates the Namespace selector and the value patterns of the rules of the NamespaceLabelPolicy
+func validatePolicy(policy *NamespaceLabelPolicy) error {
+	var errs field.ErrorList
+	errs = append(errs, validateNamespaceSelector(policy.Spec.NamespaceSelector, field.NewPath("spec", "namespaceSelector"))...)
+	errs = append(errs, validatePolicyRules(policy.Spec.Allow, field.NewPath("spec", "allow"))...)
+	errs = append(errs, validatePolicyRules(policy.Spec.Deny, field.NewPath("spec", "deny"))...)
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("NamespaceLabelPolicy").GroupKind(), policy.Name, errs)
+}
+
+func validateNamespaceSelector(selector NamespaceSelector, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if selector.LabelSelector != nil {
+		errs = append(errs, metav1validation.ValidateLabelSelector(selector.LabelSelector, fldPath.Child("labelSelector"))...)
+	}
+	for i, name := range selector.Names {
+		if _, err := path.Match(name, ""); err != nil {
+			errs = append(errs, field.Invalid(fldPath.Child("names").Index(i), name, err.Error()))
+		}
+	}
+	return errs
+}
+
+func validatePolicyRules(rules []LabelPolicyRule, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for i, rule := range rules {
+		for j, pattern := range rule.ValuePatterns {
+			if _, err := CompileValuePattern(pattern); err != nil {
+				errs = append(errs, field.Invalid(fldPath.Index(i).Child("valuePatterns").Index(j), pattern, err.Error()))
+			}
+		}
+	}
+	return errs
+}
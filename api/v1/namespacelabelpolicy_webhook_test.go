@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("NamespaceLabelPolicy Webhook", func() {
+
+	ctx := context.Background()
+	validator := &NamespaceLabelPolicyValidator{}
+
+	newPolicy := func(deny ...LabelPolicyRule) *NamespaceLabelPolicy {
+		return &NamespaceLabelPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenants"},
+			Spec: NamespaceLabelPolicySpec{
+				NamespaceSelector: NamespaceSelector{Names: []string{"tenant-*"}},
+				Deny:              deny,
+			},
+		}
+	}
+
+	It("Should accept valid value patterns", func() {
+		policy := newPolicy(LabelPolicyRule{Keys: []string{"tier"}, ValuePatterns: []string{"prod|staging", "gold-[0-9]+"}})
+
+		Expect(validator.ValidateCreate(ctx, policy)).To(Succeed())
+	})
+
+	It("Should reject value patterns which do not compile", func() {
+		policy := newPolicy(LabelPolicyRule{Keys: []string{"tier"}, ValuePatterns: []string{"prod", "gold-[0-9"}})
+		policy.Spec.Allow = []LabelPolicyRule{{ValuePatterns: []string{"(unclosed"}}}
+
+		err := validator.ValidateUpdate(ctx, newPolicy(), policy)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`spec.deny[0].valuePatterns[1]: Invalid value: "gold-[0-9"`))
+		Expect(err.Error()).To(ContainSubstring(`spec.allow[0].valuePatterns[0]: Invalid value: "(unclosed"`))
+	})
+
+	It("Should reject invalid Namespace selectors", func() {
+		policy := newPolicy()
+		policy.Spec.NamespaceSelector = NamespaceSelector{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"not a key": "true"}},
+			Names:         []string{"tenant-["},
+		}
+
+		err := validator.ValidateCreate(ctx, policy)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.namespaceSelector.labelSelector.matchLabels"))
+		Expect(err.Error()).To(ContainSubstring(`spec.namespaceSelector.names[0]: Invalid value: "tenant-["`))
+	})
+
+	It("Should compile every value pattern once", func() {
+		first, err := CompileValuePattern("cached-[a-z]+")
+		Expect(err).ShouldNot(HaveOccurred())
+		second, err := CompileValuePattern("cached-[a-z]+")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(second).Should(BeIdenticalTo(first))
+		Expect(first.MatchString("cached-value")).Should(BeTrue())
+		Expect(first.MatchString("not-cached-value")).Should(BeFalse())
+	})
+
+	It("Should not cache invalid value patterns", func() {
+		_, err := CompileValuePattern("invalid-[a-z")
+		Expect(err).Should(HaveOccurred())
+		_, cached := valuePatterns.Get("invalid-[a-z")
+		Expect(cached).Should(BeFalse())
+	})
+})
@@ -0,0 +1,92 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+)
+
+var _ conversion.Convertible = &NamespaceLabel{}
+
+// ConvertTo converts this NamespaceLabel to the Hub version (v1)
+func (src *NamespaceLabel) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*idandanielv1.NamespaceLabel)
+	dst.ObjectMeta = src.ObjectMeta
+
+	var labels map[string]string
+	var policies map[string]idandanielv1.ApplyPolicy
+	if src.Spec.Labels != nil {
+		labels = make(map[string]string, len(src.Spec.Labels))
+		for _, entry := range src.Spec.Labels {
+			labels[entry.Key] = entry.Value
+			if entry.ApplyPolicy == "" {
+				continue
+			}
+			if policies == nil {
+				policies = make(map[string]idandanielv1.ApplyPolicy)
+			}
+			policies[entry.Key] = entry.ApplyPolicy
+		}
+	}
+	dst.Spec = idandanielv1.NamespaceLabelSpec{
+		Labels:         labels,
+		ApplyPolicies:  policies,
+		LabelsFrom:     src.Spec.LabelsFrom,
+		Annotations:    src.Spec.Annotations,
+		Priority:       src.Spec.Priority,
+		ConflictPolicy: src.Spec.ConflictPolicy,
+		Mode:           src.Spec.Mode,
+		Templated:      src.Spec.Templated,
+		RemoveLabels:   src.Spec.RemoveLabels,
+		ResyncInterval: src.Spec.ResyncInterval,
+	}
+	dst.Status = src.Status
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1) to this version, the labels are sorted by key
+func (dst *NamespaceLabel) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*idandanielv1.NamespaceLabel)
+	dst.ObjectMeta = src.ObjectMeta
+
+	var labels []LabelEntry
+	if src.Spec.Labels != nil {
+		keys := maps.Keys(src.Spec.Labels)
+		slices.Sort(keys)
+		labels = make([]LabelEntry, 0, len(keys))
+		for _, key := range keys {
+			labels = append(labels, LabelEntry{Key: key, Value: src.Spec.Labels[key], ApplyPolicy: src.Spec.ApplyPolicies[key]})
+		}
+	}
+	dst.Spec = NamespaceLabelSpec{
+		Labels:         labels,
+		LabelsFrom:     src.Spec.LabelsFrom,
+		Annotations:    src.Spec.Annotations,
+		Priority:       src.Spec.Priority,
+		ConflictPolicy: src.Spec.ConflictPolicy,
+		Mode:           src.Spec.Mode,
+		Templated:      src.Spec.Templated,
+		RemoveLabels:   src.Spec.RemoveLabels,
+		ResyncInterval: src.Spec.ResyncInterval,
+	}
+	dst.Status = src.Status
+	return nil
+}
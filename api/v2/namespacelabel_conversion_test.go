@@ -0,0 +1,85 @@
+package v2
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+)
+
+var _ = Describe("NamespaceLabel Conversion", func() {
+
+	newHub := func() *idandanielv1.NamespaceLabel {
+		return &idandanielv1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: "converted", Namespace: "tenant", Annotations: map[string]string{"note": "kept"}},
+			Spec: idandanielv1.NamespaceLabelSpec{
+				Labels:         map[string]string{"tier": "gold", "team": "payments"},
+				LabelsFrom:     []idandanielv1.LabelsSource{{Prefix: "cost/", ConfigMapRef: &idandanielv1.SourceReference{Name: "costs"}}},
+				Annotations:    map[string]string{"owner": "payments"},
+				Priority:       3,
+				ConflictPolicy: idandanielv1.HighestPriority,
+				Mode:           idandanielv1.Audit,
+				Templated:      true,
+				ApplyPolicies:  map[string]idandanielv1.ApplyPolicy{"team": idandanielv1.ApplyIfAbsent},
+				RemoveLabels:   []string{"team-old", "legacy.example.com/*"},
+				ResyncInterval: &metav1.Duration{Duration: time.Hour},
+			},
+			Status: idandanielv1.NamespaceLabelStatus{
+				ObservedGeneration: 2,
+				AppliedLabels:      map[string]string{"tier": "gold"},
+				SkippedLabels:      []idandanielv1.SkippedLabel{{Key: "team", Value: "payments", Reason: idandanielv1.ReasonLabelConflict}},
+				ApplyPolicies:      map[string]idandanielv1.ApplyPolicy{"team": idandanielv1.ApplyEnforce, "tier": idandanielv1.ApplyEnforce},
+			},
+		}
+	}
+
+	It("Should convert the labels and their apply policies to entries sorted by key", func() {
+		nl := &NamespaceLabel{}
+		Expect(nl.ConvertFrom(newHub())).To(Succeed())
+
+		Expect(nl.Spec.Labels).To(Equal([]LabelEntry{
+			{Key: "team", Value: "payments", ApplyPolicy: idandanielv1.ApplyIfAbsent},
+			{Key: "tier", Value: "gold"},
+		}))
+		Expect(nl.Spec.Priority).To(Equal(int32(3)))
+		Expect(nl.Status.AppliedLabels).To(Equal(map[string]string{"tier": "gold"}))
+	})
+
+	It("Should round-trip from the hub", func() {
+		nl := &NamespaceLabel{}
+		Expect(nl.ConvertFrom(newHub())).To(Succeed())
+
+		hub := &idandanielv1.NamespaceLabel{}
+		Expect(nl.ConvertTo(hub)).To(Succeed())
+		Expect(hub).To(Equal(newHub()))
+	})
+
+	It("Should round-trip to the hub", func() {
+		nl := &NamespaceLabel{}
+		Expect(nl.ConvertFrom(newHub())).To(Succeed())
+		original := nl.DeepCopy()
+
+		hub := &idandanielv1.NamespaceLabel{}
+		Expect(nl.ConvertTo(hub)).To(Succeed())
+		converted := &NamespaceLabel{}
+		Expect(converted.ConvertFrom(hub)).To(Succeed())
+		Expect(converted).To(Equal(original))
+	})
+
+	It("Should keep labels without values and NamespaceLabels without labels", func() {
+		nl := &NamespaceLabel{Spec: NamespaceLabelSpec{Labels: []LabelEntry{{Key: "flag"}}}}
+		hub := &idandanielv1.NamespaceLabel{}
+		Expect(nl.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.Labels).To(Equal(map[string]string{"flag": ""}))
+		Expect(hub.Spec.ApplyPolicies).To(BeNil())
+
+		empty := &NamespaceLabel{}
+		Expect(empty.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Spec.Labels).To(BeNil())
+		Expect(empty.ConvertFrom(hub)).To(Succeed())
+		Expect(empty.Spec.Labels).To(BeNil())
+	})
+})
@@ -0,0 +1,120 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+)
+
+// LabelEntry is a label to set on the Namespace, with room for the options of the label
+type LabelEntry struct {
+	// Key of the label
+	Key string `json:"key"`
+
+	// Value of the label
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// ApplyPolicy decides how the label is applied to the Namespace, defaults to Enforce
+	// +optional
+	ApplyPolicy idandanielv1.ApplyPolicy `json:"applyPolicy,omitempty"`
+}
+
+// NamespaceLabelSpec defines the desired state of NamespaceLabel
+type NamespaceLabelSpec struct {
+	// Labels to set on the Namespace the NamespaceLabel is in
+	// +optional
+	// +listType=map
+	// +listMapKey=key
+	Labels []LabelEntry `json:"labels,omitempty"`
+
+	// LabelsFrom sets the data of ConfigMaps as labels of the Namespace. Later sources override the keys
+	// of earlier ones, and the Labels override them all.
+	// +optional
+	LabelsFrom []idandanielv1.LabelsSource `json:"labelsFrom,omitempty"`
+
+	// Annotations to set on the Namespace the NamespaceLabel is in, they are merged, protected and owned like the labels
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Priority of the NamespaceLabel, used when every conflicting NamespaceLabel has the HighestPriority policy
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// ConflictPolicy decides which NamespaceLabel wins when several NamespaceLabels set the same key to different values.
+	// Priorities are compared only when all the NamespaceLabels competing over a key use HighestPriority,
+	// otherwise the oldest NamespaceLabel wins. NamespaceLabels with the Refuse policy never apply a conflicting key.
+	// +kubebuilder:default=FirstWriterWins
+	// +optional
+	ConflictPolicy idandanielv1.ConflictPolicy `json:"conflictPolicy,omitempty"`
+
+	// Mode is Enforce to apply the labels and annotations, or Audit to report the changes they would make to the
+	// Namespace without applying them. Switching to Audit keeps the labels and annotations the NamespaceLabel applied
+	// with their current values, until it is enforced again or deleted.
+	// +kubebuilder:default=Enforce
+	// +optional
+	Mode idandanielv1.Mode `json:"mode,omitempty"`
+
+	// Templated renders the label values as Go templates with the name, labels and annotations of the Namespace,
+	// e.g. `{{ .Namespace.Annotations.owner }}` or `{{ trimPrefix "team-" .Namespace.Name }}`.
+	// Labels which fail to render or render to invalid values are skipped.
+	// +optional
+	Templated bool `json:"templated,omitempty"`
+
+	// RemoveLabels are label keys, or key prefixes ending with `*`, removed from the Namespace on every sync whoever
+	// set them. Protected labels and the labels set by NamespaceLabels and ClusterNamespaceLabels are kept.
+	// +optional
+	RemoveLabels []string `json:"removeLabels,omitempty"`
+
+	// ResyncInterval is how often the NamespaceLabel is synced again when nothing changed, correcting the drift
+	// missed events left on the Namespace. It overrides the --resync-period of the manager, and up to 10% of jitter
+	// is added so the NamespaceLabels are not synced all at once.
+	// +optional
+	ResyncInterval *metav1.Duration `json:"resyncInterval,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Synced",type=string,JSONPath=`.status.conditions[?(@.type=="Synced")].status`
+//+kubebuilder:printcolumn:name="Conflicted",type=string,JSONPath=`.status.conditions[?(@.type=="Conflicted")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// NamespaceLabel is the Schema for the namespacelabels API, its labels are structured entries.
+// It is converted to and from the v1 NamespaceLabel which is stored.
+type NamespaceLabel struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NamespaceLabelSpec                `json:"spec,omitempty"`
+	Status idandanielv1.NamespaceLabelStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// NamespaceLabelList contains a list of NamespaceLabel
+type NamespaceLabelList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NamespaceLabel `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NamespaceLabel{}, &NamespaceLabelList{})
+}
@@ -0,0 +1,120 @@
+package logging
+
+import (
+	"flag"
+	"fmt"
+
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// ECSVersion is the version of the Elastic Common Schema written by the ECS encoder
+const ECSVersion = "1.6.0"
+
+// Log formats selectable with the --log-format flag
+const (
+	// FormatECS writes Elastic Common Schema JSON, the default
+	FormatECS = "ecs"
+	// FormatZap uses the encoder selected by the --zap-encoder flag
+	FormatZap = "zap"
+)
+
+// ECSEncoderConfig returns the encoder configuration writing the ECS fields
+func ECSEncoderConfig() zapcore.EncoderConfig {
+	return zapcore.EncoderConfig{
+		TimeKey:        "@timestamp",
+		LevelKey:       "log.level",
+		NameKey:        "log.logger",
+		MessageKey:     "message",
+		StacktraceKey:  "error.stack_trace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.NanosDurationEncoder,
+		EncodeName:     zapcore.FullNameEncoder,
+	}
+}
+
+// NewECSEncoder returns a JSON encoder writing Elastic Common Schema log lines.
+// The timestamp is always ISO8601 as ECS requires, whatever time encoding the options set.
+func NewECSEncoder(opts ...zap.EncoderConfigOption) zapcore.Encoder {
+	config := ECSEncoderConfig()
+	for _, opt := range opts {
+		opt(&config)
+	}
+	config.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	encoder := zapcore.NewJSONEncoder(config)
+	encoder.AddString("ecs.version", ECSVersion)
+	return &ecsEncoder{Encoder: encoder}
+}
+
+// ecsEncoder writes the logged errors to the ECS error.message field
+type ecsEncoder struct {
+	zapcore.Encoder
+}
+
+func (e *ecsEncoder) Clone() zapcore.Encoder {
+	return &ecsEncoder{Encoder: e.Encoder.Clone()}
+}
+
+func (e *ecsEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	ecsFields := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		if field.Key == "error" {
+			field.Key = "error.message"
+		}
+		ecsFields[i] = field
+	}
+	return e.Encoder.EncodeEntry(entry, ecsFields)
+}
+
+// formatFlag selects the encoder of the zap options from the log format name
+type formatFlag struct {
+	opts   *zap.Options
+	format string
+}
+
+func (f *formatFlag) String() string {
+	return f.format
+}
+
+func (f *formatFlag) Set(format string) error {
+	switch format {
+	case FormatZap, FormatECS:
+	default:
+		return fmt.Errorf("invalid log format %q, expected %q or %q", format, FormatZap, FormatECS)
+	}
+	f.format = format
+	return nil
+}
+
+// Build the encoder of the selected format when the logger is built, once every flag is parsed. The zap format uses
+// the default encoder of the zap options, the console one in development mode and JSON otherwise.
+func (f *formatFlag) newEncoder(opts ...zap.EncoderConfigOption) zapcore.Encoder {
+	if f.format == FormatECS {
+		return NewECSEncoder(opts...)
+	}
+	config := uberzap.NewProductionEncoderConfig()
+	if f.opts.Development {
+		config = uberzap.NewDevelopmentEncoderConfig()
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if f.opts.Development {
+		return zapcore.NewConsoleEncoder(config)
+	}
+	return zapcore.NewJSONEncoder(config)
+}
+
+// BindFlags binds the --log-format flag selecting the encoder of the zap options, ECS by default. It has to be called
+// after the zap options bound their flags: an explicit --zap-encoder replaces the encoder of the log format.
+func BindFlags(fs *flag.FlagSet, opts *zap.Options) {
+	format := &formatFlag{opts: opts, format: FormatECS}
+	opts.NewEncoder = format.newEncoder
+	fs.Var(format, "log-format",
+		fmt.Sprintf("Log format, %q for Elastic Common Schema JSON or %q for the --zap-encoder encoder", FormatECS, FormatZap))
+}
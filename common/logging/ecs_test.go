@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestNewECSEncoder(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []zapcore.Field
+		want   map[string]interface{}
+	}{
+		{
+			name: "entry",
+			want: map[string]interface{}{
+				"@timestamp":  "2022-10-16T12:00:00.000Z",
+				"log.level":   "info",
+				"log.logger":  "controller",
+				"message":     "Synced",
+				"ecs.version": ECSVersion,
+			},
+		},
+		{
+			name:   "error renamed to error.message",
+			fields: []zapcore.Field{{Key: "error", Type: zapcore.ErrorType, Interface: errors.New("not found")}},
+			want:   map[string]interface{}{"error.message": "not found"},
+		},
+		{
+			name:   "other fields kept",
+			fields: []zapcore.Field{{Key: "Namespace", Type: zapcore.StringType, String: "payments"}},
+			want:   map[string]interface{}{"Namespace": "payments"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := zapcore.Entry{
+				Level:      zapcore.InfoLevel,
+				Time:       time.Date(2022, 10, 16, 12, 0, 0, 0, time.UTC),
+				LoggerName: "controller",
+				Message:    "Synced",
+			}
+			// The time encoding of the options is ignored, ECS timestamps are ISO8601
+			encoder := NewECSEncoder(func(config *zapcore.EncoderConfig) { config.EncodeTime = zapcore.EpochTimeEncoder })
+			buffer, err := encoder.EncodeEntry(entry, tt.fields)
+			if err != nil {
+				t.Fatalf("EncodeEntry() error = %v", err)
+			}
+			got := make(map[string]interface{})
+			if err := json.Unmarshal(buffer.Bytes(), &got); err != nil {
+				t.Fatalf("EncodeEntry() wrote invalid JSON %q: %v", buffer.String(), err)
+			}
+			if _, exists := got["error"]; exists {
+				t.Errorf("EncodeEntry() = %v, want no error field", got)
+			}
+			for key, value := range tt.want {
+				if got[key] != value {
+					t.Errorf("EncodeEntry()[%q] = %v, want %v", key, got[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestLogFormatFlag(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantECS bool
+		wantErr bool
+	}{
+		{name: "default", wantECS: true},
+		{name: "ecs", args: []string{"--log-format=ecs"}, wantECS: true},
+		{name: "zap", args: []string{"--log-format=zap"}},
+		{name: "zap encoder", args: []string{"--zap-encoder=json"}},
+		{name: "zap encoder before ecs", args: []string{"--zap-encoder=json", "--log-format=ecs"}},
+		{name: "invalid", args: []string{"--log-format=text"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet(tt.name, flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			opts := &zap.Options{Development: true}
+			opts.BindFlags(fs)
+			BindFlags(fs, opts)
+			err := fs.Parse(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if _, isECS := opts.NewEncoder().(*ecsEncoder); isECS != tt.wantECS {
+				t.Errorf("NewEncoder() is ECS = %v, want %v", isECS, tt.wantECS)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package protected
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// ConfigMap data keys holding the protected label rules
+const (
+	KeysField     = "keys"
+	PrefixesField = "prefixes"
+	PatternsField = "patterns"
+)
+
+// ConfigMap data keys holding the protected annotation rules
+const (
+	AnnotationKeysField     = "annotationKeys"
+	AnnotationPrefixesField = "annotationPrefixes"
+	AnnotationPatternsField = "annotationPatterns"
+)
+
+// DefaultPrefixes are the label prefixes managed by Kubernetes itself
+var DefaultPrefixes = []string{"kubernetes.io", "k8s.io"}
+
+// DefaultAnnotationPrefixes are the annotation prefixes managed by kubectl
+var DefaultAnnotationPrefixes = []string{"kubectl.kubernetes.io"}
+
+// Rules decides which labels or annotations NamespaceLabels may never change
+type Rules struct {
+	// Keys are exact label keys
+	Keys []string
+	// Prefixes are DNS prefixes, a prefix protects its own keys and the keys of its subdomains
+	Prefixes []string
+	// Patterns are regular expressions matched against the whole label key
+	Patterns []*regexp.Regexp
+}
+
+// DefaultRules protects the labels managed by Kubernetes
+func DefaultRules() *Rules {
+	return &Rules{Prefixes: DefaultPrefixes}
+}
+
+// DefaultAnnotationRules protects the annotations managed by kubectl
+func DefaultAnnotationRules() *Rules {
+	return &Rules{Prefixes: DefaultAnnotationPrefixes}
+}
+
+// NewRules builds Rules, failing on invalid patterns
+func NewRules(keys []string, prefixes []string, patterns []string) (*Rules, error) {
+	rules := &Rules{Keys: keys, Prefixes: prefixes}
+
+	for _, pattern := range patterns {
+		compiled, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid protected pattern %q: %w", pattern, err)
+		}
+		rules.Patterns = append(rules.Patterns, compiled)
+	}
+
+	return rules, nil
+}
+
+// RulesFromConfigMap builds Rules from the keys, prefixes and patterns fields of a ConfigMap
+func RulesFromConfigMap(configMap *v1.ConfigMap) (*Rules, error) {
+	return NewRules(
+		SplitList(configMap.Data[KeysField]),
+		SplitList(configMap.Data[PrefixesField]),
+		SplitList(configMap.Data[PatternsField]),
+	)
+}
+
+// AnnotationRulesFromConfigMap builds Rules from the annotationKeys, annotationPrefixes and annotationPatterns fields of a ConfigMap
+func AnnotationRulesFromConfigMap(configMap *v1.ConfigMap) (*Rules, error) {
+	return NewRules(
+		SplitList(configMap.Data[AnnotationKeysField]),
+		SplitList(configMap.Data[AnnotationPrefixesField]),
+		SplitList(configMap.Data[AnnotationPatternsField]),
+	)
+}
+
+// SplitList splits a comma or newline separated list, dropping empty entries
+func SplitList(list string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Merge returns Rules protecting everything either of the Rules protects
+func (r *Rules) Merge(other *Rules) *Rules {
+	return &Rules{
+		Keys:     append(append([]string{}, r.Keys...), other.Keys...),
+		Prefixes: append(append([]string{}, r.Prefixes...), other.Prefixes...),
+		Patterns: append(append([]*regexp.Regexp{}, r.Patterns...), other.Patterns...),
+	}
+}
+
+// IsProtected returns true when the label or annotation key matches any of the rules
+func (r *Rules) IsProtected(key string) bool {
+	for _, protectedKey := range r.Keys {
+		if key == protectedKey {
+			return true
+		}
+	}
+
+	if prefix, _, hasPrefix := strings.Cut(key, "/"); hasPrefix {
+		for _, protectedPrefix := range r.Prefixes {
+			if prefix == protectedPrefix || strings.HasSuffix(prefix, "."+protectedPrefix) {
+				return true
+			}
+		}
+	}
+
+	for _, pattern := range r.Patterns {
+		if pattern.MatchString(key) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,110 @@
+package protected
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestIsProtected(t *testing.T) {
+	rules, err := NewRules([]string{"team"}, []string{"example.com"}, []string{"tier-[0-9]+"})
+	if err != nil {
+		t.Fatalf("NewRules() error = %v", err)
+	}
+	tests := []struct {
+		name  string
+		rules *Rules
+		key   string
+		want  bool
+	}{
+		{name: "kubernetes prefix", rules: DefaultRules(), key: "kubernetes.io/metadata.name", want: true},
+		{name: "kubernetes subdomain", rules: DefaultRules(), key: "app.kubernetes.io/name", want: true},
+		{name: "k8s prefix", rules: DefaultRules(), key: "k8s.io/component", want: true},
+		{name: "unprefixed", rules: DefaultRules(), key: "team"},
+		{name: "prefix suffix without a dot", rules: DefaultRules(), key: "notkubernetes.io/name"},
+		{name: "prefix in the name", rules: DefaultRules(), key: "example.com/kubernetes.io"},
+		{name: "kubectl annotation", rules: DefaultAnnotationRules(), key: "kubectl.kubernetes.io/last-applied-configuration", want: true},
+		{name: "other annotation", rules: DefaultAnnotationRules(), key: "scheduler.alpha.kubernetes.io/node-selector"},
+		{name: "key", rules: rules, key: "team", want: true},
+		{name: "other key", rules: rules, key: "teams"},
+		{name: "prefix", rules: rules, key: "example.com/team", want: true},
+		{name: "pattern", rules: rules, key: "tier-1", want: true},
+		{name: "pattern matches the whole key", rules: rules, key: "tier-1a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rules.IsProtected(tt.key); got != tt.want {
+				t.Errorf("IsProtected(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRulesInvalidPattern(t *testing.T) {
+	if _, err := NewRules(nil, nil, []string{"tier-("}); err == nil {
+		t.Error("NewRules() error = nil, want an invalid pattern error")
+	}
+}
+
+func TestRulesFromConfigMap(t *testing.T) {
+	configMap := &v1.ConfigMap{Data: map[string]string{
+		KeysField:               "team, tier\n",
+		PrefixesField:           "example.com",
+		PatternsField:           "cost-.*",
+		AnnotationKeysField:     "owner",
+		AnnotationPrefixesField: "\nexample.org,,",
+	}}
+
+	rules, err := RulesFromConfigMap(configMap)
+	if err != nil {
+		t.Fatalf("RulesFromConfigMap() error = %v", err)
+	}
+	if !reflect.DeepEqual(rules.Keys, []string{"team", "tier"}) || !reflect.DeepEqual(rules.Prefixes, []string{"example.com"}) {
+		t.Errorf("RulesFromConfigMap() = %+v, want the keys team and tier and the prefix example.com", rules)
+	}
+	if !rules.IsProtected("cost-center") || rules.IsProtected("owner") {
+		t.Errorf("RulesFromConfigMap() = %+v, want only the label rules", rules)
+	}
+
+	annotationRules, err := AnnotationRulesFromConfigMap(configMap)
+	if err != nil {
+		t.Fatalf("AnnotationRulesFromConfigMap() error = %v", err)
+	}
+	if !reflect.DeepEqual(annotationRules.Keys, []string{"owner"}) || !reflect.DeepEqual(annotationRules.Prefixes, []string{"example.org"}) {
+		t.Errorf("AnnotationRulesFromConfigMap() = %+v, want the key owner and the prefix example.org", annotationRules)
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		list string
+		want []string
+	}{
+		{list: ""},
+		{list: " , \n"},
+		{list: "team", want: []string{"team"}},
+		{list: "team, tier\ncost\n", want: []string{"team", "tier", "cost"}},
+	}
+	for _, tt := range tests {
+		if got := SplitList(tt.list); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitList(%q) = %q, want %q", tt.list, got, tt.want)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	labels, err := NewRules([]string{"team"}, nil, []string{"tier-.*"})
+	if err != nil {
+		t.Fatalf("NewRules() error = %v", err)
+	}
+	merged := DefaultRules().Merge(labels)
+	for _, key := range []string{"team", "tier-1", "kubernetes.io/metadata.name"} {
+		if !merged.IsProtected(key) {
+			t.Errorf("Merge().IsProtected(%q) = false, want true", key)
+		}
+	}
+	if !reflect.DeepEqual(DefaultRules().Prefixes, DefaultPrefixes) || len(labels.Prefixes) != 0 {
+		t.Error("Merge() changed the merged rules")
+	}
+}
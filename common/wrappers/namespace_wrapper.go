@@ -1,51 +1,523 @@
 package wrappers
 
 import (
+	"encoding/json"
 	"strings"
 
 	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+	"idandaniel.io/namespacelabel-demo/common/protected"
 )
 
+// OwnedLabelsAnnotation lists the label keys set on the Namespace by NamespaceLabels
+const OwnedLabelsAnnotation = "idandaniel.idandaniel.io/owned-labels"
+
+// OwnedAnnotationsAnnotation lists the annotation keys set on the Namespace by NamespaceLabels
+const OwnedAnnotationsAnnotation = "idandaniel.idandaniel.io/owned-annotations"
+
+// PreviousLabelsAnnotation holds the JSON encoded values the owned labels had before NamespaceLabels overrode them
+const PreviousLabelsAnnotation = "idandaniel.idandaniel.io/previous-labels"
+
 type NamespaceWrapper struct {
 	*v1.Namespace
+	// ProtectedLabels are the labels NamespaceLabels may never change, defaults to the Kubernetes labels
+	ProtectedLabels *protected.Rules
+	// ProtectedAnnotations are the annotations NamespaceLabels may never change, defaults to the kubectl annotations
+	ProtectedAnnotations *protected.Rules
+	// removedLabels are the keys of the labels not owned by NamespaceLabels which were removed
+	removedLabels []string
+	// restoredLabels are the labels no longer owned which were set back to their previous values
+	restoredLabels map[string]string
+	// leftLabels are the labels given up with their current values
+	leftLabels map[string]string
+	// leftAnnotations are the annotations given up with their current values
+	leftAnnotations map[string]string
+	// conflictedLabels are the keys of the labels managed by others with a different value, which are not applied
+	conflictedLabels []string
+	// conflictedAnnotations are the keys of the annotations managed by others with a different value, which are not applied
+	conflictedAnnotations []string
 }
 
-func (n *NamespaceWrapper) getManagementLabels() map[string]string {
-	protectedLabels := make(map[string]string)
+// managedField is a metadata map of the Namespace whose keys are owned by NamespaceLabels
+type managedField struct {
+	namespace *NamespaceWrapper
+	// values points to the Namespace labels or annotations
+	values *map[string]string
+	// ownedAnnotation lists the keys set by NamespaceLabels
+	ownedAnnotation string
+	// previousAnnotation holds the values of the overridden keys, the previous values are not kept when empty
+	previousAnnotation string
+	isProtected        func(key string) bool
+}
 
-	for labelKey, labelValue := range n.Labels {
-		if strings.Contains(labelKey, "kubernetes.io") {
-			protectedLabels[labelKey] = labelValue
-		}
+func (n *NamespaceWrapper) labelsField() *managedField {
+	return &managedField{namespace: n, values: &n.Labels, ownedAnnotation: OwnedLabelsAnnotation, previousAnnotation: PreviousLabelsAnnotation, isProtected: n.IsProtected}
+}
+
+func (n *NamespaceWrapper) annotationsField() *managedField {
+	return &managedField{namespace: n, values: &n.Annotations, ownedAnnotation: OwnedAnnotationsAnnotation, isProtected: n.IsAnnotationProtected}
+}
+
+func (n *NamespaceWrapper) protectedLabels() *protected.Rules {
+	if n.ProtectedLabels == nil {
+		return protected.DefaultRules()
 	}
+	return n.ProtectedLabels
+}
 
-	return protectedLabels
+func (n *NamespaceWrapper) protectedAnnotations() *protected.Rules {
+	if n.ProtectedAnnotations == nil {
+		return protected.DefaultAnnotationRules()
+	}
+	return n.ProtectedAnnotations
+}
+
+// IsProtected returns true when NamespaceLabels may not change the label
+func (n *NamespaceWrapper) IsProtected(key string) bool {
+	return n.protectedLabels().IsProtected(key)
+}
+
+// IsAnnotationProtected returns true when NamespaceLabels may not change the annotation.
+// The annotations recording the owned keys and the previous label values are always protected.
+func (n *NamespaceWrapper) IsAnnotationProtected(key string) bool {
+	return key == OwnedLabelsAnnotation || key == OwnedAnnotationsAnnotation || key == PreviousLabelsAnnotation ||
+		n.protectedAnnotations().IsProtected(key)
 }
 
+// OwnedLabels returns the sorted label keys which were set by NamespaceLabels
+func (n *NamespaceWrapper) OwnedLabels() []string {
+	return n.labelsField().owned()
+}
+
+// OwnedAnnotations returns the sorted annotation keys which were set by NamespaceLabels
+func (n *NamespaceWrapper) OwnedAnnotations() []string {
+	return n.annotationsField().owned()
+}
+
+// PreviousLabels returns the values the owned labels had before NamespaceLabels overrode them
+func (n *NamespaceWrapper) PreviousLabels() map[string]string {
+	return n.labelsField().previous()
+}
+
+// RestoredLabels returns the labels which are no longer owned and were set back to the values they had
+// before NamespaceLabels overrode them. Server-side apply removes them, they have to be set separately before applying.
+func (n *NamespaceWrapper) RestoredLabels() map[string]string {
+	return n.restoredLabels
+}
+
+// IsOwned returns true when the label was set by NamespaceLabels
+func (n *NamespaceWrapper) IsOwned(key string) bool {
+	return slices.Contains(n.OwnedLabels(), key)
+}
+
+// IsAnnotationOwned returns true when the annotation was set by NamespaceLabels
+func (n *NamespaceWrapper) IsAnnotationOwned(key string) bool {
+	return slices.Contains(n.OwnedAnnotations(), key)
+}
+
+// UpdateLabels sets the new labels on the Namespace. When safe, only labels owned by NamespaceLabels
+// are removed or replaced, protected labels are never changed and every other label is kept.
 func (n *NamespaceWrapper) UpdateLabels(safe bool, newLabels map[string]string) {
-	if !safe {
-		n.Labels = newLabels
-		return
+	n.labelsField().update(safe, newLabels)
+}
+
+// UpdateLabelsWithPolicies safely sets the new labels on the Namespace like UpdateLabels, honouring the apply policy
+// of every key. ApplyIfAbsent labels keep the value the Namespace has, and are not owned when set by others.
+// RemoveOnly labels are removed with RemoveMatchingLabels.
+func (n *NamespaceWrapper) UpdateLabelsWithPolicies(newLabels map[string]string, policies map[string]idandanielv1.ApplyPolicy) {
+	labels := make(map[string]string, len(newLabels))
+	for key, value := range newLabels {
+		current, exists := n.Labels[key]
+		if policies[key] == idandanielv1.ApplyIfAbsent {
+			if exists && !n.IsOwned(key) {
+				continue
+			}
+			if exists {
+				value = current
+			}
+		}
+		labels[key] = value
 	}
 
-	managementLabels := n.getManagementLabels()
-	maps.Copy(managementLabels, newLabels)
-	n.Labels = managementLabels
+	n.labelsField().update(true, labels)
 }
 
-func (n *NamespaceWrapper) RemoveLabel(key string, value string) {
-	if value == n.Labels[key] {
+// RemoveMatchingLabels removes the labels matching the removal from the Namespace whoever set them,
+// except the protected labels, the owned labels and the labels to keep
+func (n *NamespaceWrapper) RemoveMatchingLabels(matches func(key string) bool, labelsToKeep map[string]string) {
+	var removed []string
+	for key := range n.Labels {
+		if _, isKept := labelsToKeep[key]; isKept || !matches(key) || n.IsOwned(key) || n.IsProtected(key) {
+			continue
+		}
+		removed = append(removed, key)
+	}
+	n.removeLabels(removed)
+}
+
+func (n *NamespaceWrapper) removeLabels(keys []string) {
+	for _, key := range keys {
 		delete(n.Labels, key)
+		delete(n.restoredLabels, key)
 	}
+	n.removedLabels = append(n.removedLabels, keys...)
+	slices.Sort(n.removedLabels)
+	n.removedLabels = slices.Compact(n.removedLabels)
+}
+
+// RemovedLabels returns the sorted keys of the labels set by others which UpdateLabelsWithPolicies and RemoveMatchingLabels removed.
+// Server-side apply only removes the owned labels, these have to be removed from the Namespace separately.
+func (n *NamespaceWrapper) RemovedLabels() []string {
+	return n.removedLabels
+}
+
+// UpdateAnnotations sets the new annotations on the Namespace the same way UpdateLabels sets the labels
+func (n *NamespaceWrapper) UpdateAnnotations(safe bool, newAnnotations map[string]string) {
+	n.annotationsField().update(safe, newAnnotations)
+}
+
+// RemoveLabel removes an owned label when it has the given value
+func (n *NamespaceWrapper) RemoveLabel(key string, value string) {
+	n.labelsField().remove(key, value)
+}
+
+// RemoveAnnotation removes an owned annotation when it has the given value
+func (n *NamespaceWrapper) RemoveAnnotation(key string, value string) {
+	n.annotationsField().remove(key, value)
 }
 
 func (n *NamespaceWrapper) RemoveLabelsExcept(labelsToRemove map[string]string, labelsToIgnore map[string]string) {
-	for key, value := range labelsToRemove {
-		_, isKeyExists := labelsToIgnore[key]
-		if isKeyExists && value == n.Labels[key] {
+	n.labelsField().removeExcept(labelsToRemove, labelsToIgnore)
+}
+
+func (n *NamespaceWrapper) RemoveAnnotationsExcept(annotationsToRemove map[string]string, annotationsToIgnore map[string]string) {
+	n.annotationsField().removeExcept(annotationsToRemove, annotationsToIgnore)
+}
+
+// LeaveLabels gives up the owned labels, leaving them on the Namespace with their current values: they are no longer
+// owned and their previous values are forgotten. Server-side apply removes them, they have to be set separately before applying.
+func (n *NamespaceWrapper) LeaveLabels(keys []string) {
+	n.leftLabels = n.labelsField().leave(keys)
+}
+
+// LeaveAnnotations gives up the owned annotations the same way LeaveLabels gives up the labels
+func (n *NamespaceWrapper) LeaveAnnotations(keys []string) {
+	n.leftAnnotations = n.annotationsField().leave(keys)
+}
+
+// LeftLabels returns the labels LeaveLabels gave up, with their current values
+func (n *NamespaceWrapper) LeftLabels() map[string]string {
+	return n.leftLabels
+}
+
+// LeftAnnotations returns the annotations LeaveAnnotations gave up, with their current values
+func (n *NamespaceWrapper) LeftAnnotations() map[string]string {
+	return n.leftAnnotations
+}
+
+// SkipConflicts gives up the labels and annotations managed by others with a different value: they keep their current
+// values and are no longer owned, so they are left out of the ApplyConfiguration
+func (n *NamespaceWrapper) SkipConflicts(current *v1.Namespace, labels []string, annotations []string) {
+	n.labelsField().skip(current.Labels, labels)
+	n.annotationsField().skip(current.Annotations, annotations)
+	n.conflictedLabels = append(n.conflictedLabels, labels...)
+	n.conflictedAnnotations = append(n.conflictedAnnotations, annotations...)
+}
+
+// ConflictedLabels returns the keys of the labels SkipConflicts gave up
+func (n *NamespaceWrapper) ConflictedLabels() []string {
+	return n.conflictedLabels
+}
+
+// ConflictedAnnotations returns the keys of the annotations SkipConflicts gave up
+func (n *NamespaceWrapper) ConflictedAnnotations() []string {
+	return n.conflictedAnnotations
+}
+
+func (f *managedField) owned() []string {
+	owned := protected.SplitList(f.namespace.Annotations[f.ownedAnnotation])
+	slices.Sort(owned)
+	return owned
+}
+
+func (f *managedField) setOwned(keys []string) {
+	if len(keys) == 0 {
+		delete(f.namespace.Annotations, f.ownedAnnotation)
+		return
+	}
+
+	if f.namespace.Annotations == nil {
+		f.namespace.Annotations = make(map[string]string)
+	}
+	slices.Sort(keys)
+	f.namespace.Annotations[f.ownedAnnotation] = strings.Join(keys, ",")
+}
+
+// Get the values the owned keys had before they were overridden
+func (f *managedField) previous() map[string]string {
+	previous := make(map[string]string)
+	if f.previousAnnotation == "" {
+		return previous
+	}
+	if encoded, exists := f.namespace.Annotations[f.previousAnnotation]; exists {
+		// A corrupted annotation only loses the previous values
+		_ = json.Unmarshal([]byte(encoded), &previous)
+	}
+	return previous
+}
+
+func (f *managedField) setPrevious(previous map[string]string) {
+	if f.previousAnnotation == "" {
+		return
+	}
+	if len(previous) == 0 {
+		delete(f.namespace.Annotations, f.previousAnnotation)
+		return
+	}
+
+	encoded, err := json.Marshal(previous)
+	if err != nil {
+		return
+	}
+	if f.namespace.Annotations == nil {
+		f.namespace.Annotations = make(map[string]string)
+	}
+	f.namespace.Annotations[f.previousAnnotation] = string(encoded)
+}
+
+// Remove a key which is no longer owned from the values, restoring the value it had before it was overridden
+func (f *managedField) release(values map[string]string, previous map[string]string, key string) {
+	previousValue, wasOverridden := previous[key]
+	if !wasOverridden {
+		delete(values, key)
+		return
+	}
+
+	values[key] = previousValue
+	delete(previous, key)
+	if f.namespace.restoredLabels == nil {
+		f.namespace.restoredLabels = make(map[string]string)
+	}
+	f.namespace.restoredLabels[key] = previousValue
+}
+
+func (f *managedField) update(safe bool, newValues map[string]string) {
+	if !safe {
+		*f.values = maps.Clone(newValues)
+		f.setOwned(maps.Keys(newValues))
+		return
+	}
+
+	values := maps.Clone(*f.values)
+	if values == nil {
+		values = make(map[string]string)
+	}
+	previous := f.previous()
+
+	// Remove the owned keys which are no longer wanted
+	owned := f.owned()
+	for _, key := range owned {
+		if _, isWanted := newValues[key]; !isWanted && !f.isProtected(key) {
+			f.release(values, previous, key)
+		}
+	}
+
+	// Protected keys are kept as they are and never overwritten, the values of the keys set by others are kept
+	// to be restored once no longer wanted
+	newOwned := make([]string, 0, len(newValues))
+	for key, value := range newValues {
+		if f.isProtected(key) {
+			continue
+		}
+		if currentValue, exists := values[key]; exists && currentValue != value && !slices.Contains(owned, key) {
+			previous[key] = currentValue
+		}
+		values[key] = value
+		newOwned = append(newOwned, key)
+	}
+
+	*f.values = values
+	f.setOwned(newOwned)
+	f.setPrevious(previous)
+}
+
+func (f *managedField) remove(key string, value string) {
+	if !slices.Contains(f.owned(), key) || value != (*f.values)[key] {
+		return
+	}
+
+	previous := f.previous()
+	f.release(*f.values, previous, key)
+	f.setPrevious(previous)
+	owned := f.owned()
+	index := slices.Index(owned, key)
+	f.setOwned(slices.Delete(owned, index, index+1))
+}
+
+func (f *managedField) removeExcept(valuesToRemove map[string]string, valuesToIgnore map[string]string) {
+	for key, value := range valuesToRemove {
+		_, isKeyExists := valuesToIgnore[key]
+		if isKeyExists && value == (*f.values)[key] {
+			continue
+		}
+		if f.isProtected(key) {
+			continue
+		}
+		f.remove(key, value)
+	}
+}
+
+// Give up the keys, setting them back to their current values without restoring their previous values
+func (f *managedField) skip(current map[string]string, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	if *f.values == nil {
+		*f.values = make(map[string]string)
+	}
+	previous := f.previous()
+	owned := f.owned()
+	for _, key := range keys {
+		if value, exists := current[key]; exists {
+			(*f.values)[key] = value
+		} else {
+			delete(*f.values, key)
+		}
+		delete(previous, key)
+		if index := slices.Index(owned, key); index >= 0 {
+			owned = slices.Delete(owned, index, index+1)
+		}
+	}
+	f.setOwned(owned)
+	f.setPrevious(previous)
+}
+
+// Give up the owned keys, keeping their current values, returning the values of the keys the Namespace has
+func (f *managedField) leave(keys []string) map[string]string {
+	left := make(map[string]string)
+	previous := f.previous()
+	owned := f.owned()
+	for _, key := range keys {
+		index := slices.Index(owned, key)
+		if index < 0 || f.isProtected(key) {
 			continue
 		}
-		n.RemoveLabel(key, value)
+		owned = slices.Delete(owned, index, index+1)
+		delete(previous, key)
+		if value, exists := (*f.values)[key]; exists {
+			left[key] = value
+		}
 	}
+	f.setOwned(owned)
+	f.setPrevious(previous)
+	return left
+}
+
+// HasChanges returns true when writing the wrapped Namespace would change the current one: a label or annotation
+// differs, a label set by others is removed or restored, a key is given up, or the keys the field manager applies are not the keys of
+// the ApplyConfiguration, e.g. when another field manager took some of them over
+func (n *NamespaceWrapper) HasChanges(current *v1.Namespace, fieldManager string) bool {
+	if len(n.removedLabels) > 0 || len(n.restoredLabels) > 0 || len(n.leftLabels) > 0 || len(n.leftAnnotations) > 0 {
+		return true
+	}
+	if !maps.Equal(current.Labels, n.Labels) || !maps.Equal(current.Annotations, n.Annotations) {
+		return true
+	}
+
+	labels, annotations, err := appliedKeys(current, fieldManager)
+	if err != nil {
+		return true
+	}
+	applyConfiguration := n.ApplyConfiguration()
+	return !hasSameKeys(labels, applyConfiguration.Labels) || !hasSameKeys(annotations, applyConfiguration.Annotations)
+}
+
+// appliedFields are the metadata fields of a managed fields entry
+type appliedFields struct {
+	Metadata struct {
+		Labels      map[string]json.RawMessage `json:"f:labels"`
+		Annotations map[string]json.RawMessage `json:"f:annotations"`
+	} `json:"f:metadata"`
+}
+
+// Get the sorted label and annotation keys the field manager applies to the Namespace
+func appliedKeys(namespace *v1.Namespace, fieldManager string) ([]string, []string, error) {
+	applied := slices.IndexFunc(namespace.ManagedFields, func(entry metav1.ManagedFieldsEntry) bool {
+		return entry.Manager == fieldManager && entry.Operation == metav1.ManagedFieldsOperationApply
+	})
+	if applied < 0 || namespace.ManagedFields[applied].FieldsV1 == nil {
+		return nil, nil, nil
+	}
+
+	fields := &appliedFields{}
+	if err := json.Unmarshal(namespace.ManagedFields[applied].FieldsV1.Raw, fields); err != nil {
+		return nil, nil, err
+	}
+	return fieldKeys(fields.Metadata.Labels), fieldKeys(fields.Metadata.Annotations), nil
+}
+
+// Get the sorted keys of the "f:<key>" entries of a map field
+func fieldKeys(fields map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(fields))
+	for field := range fields {
+		if strings.HasPrefix(field, "f:") {
+			keys = append(keys, strings.TrimPrefix(field, "f:"))
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func hasSameKeys(keys []string, values map[string]string) bool {
+	expected := maps.Keys(values)
+	slices.Sort(expected)
+	return slices.Equal(keys, expected)
+}
+
+// ApplyConfiguration returns a Namespace holding only the fields owned by NamespaceLabels, to be server-side applied.
+// Owned fields missing from it are removed by the API server.
+func (n *NamespaceWrapper) ApplyConfiguration() *v1.Namespace {
+	namespace := &v1.Namespace{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1.SchemeGroupVersion.String(),
+			Kind:       "Namespace",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: n.Name,
+		},
+	}
+
+	if owned := n.OwnedLabels(); len(owned) > 0 {
+		namespace.Labels = make(map[string]string, len(owned))
+		for _, key := range owned {
+			if value, exists := n.Labels[key]; exists {
+				namespace.Labels[key] = value
+			}
+		}
+		namespace.Annotations = map[string]string{
+			OwnedLabelsAnnotation: n.Annotations[OwnedLabelsAnnotation],
+		}
+		if previous, exists := n.Annotations[PreviousLabelsAnnotation]; exists {
+			namespace.Annotations[PreviousLabelsAnnotation] = previous
+		}
+	}
+
+	if owned := n.OwnedAnnotations(); len(owned) > 0 {
+		if namespace.Annotations == nil {
+			namespace.Annotations = make(map[string]string, len(owned)+1)
+		}
+		for _, key := range owned {
+			if value, exists := n.Annotations[key]; exists {
+				namespace.Annotations[key] = value
+			}
+		}
+		namespace.Annotations[OwnedAnnotationsAnnotation] = n.Annotations[OwnedAnnotationsAnnotation]
+	}
+
+	return namespace
 }
@@ -0,0 +1,238 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+)
+
+// clusterNamespaceLabelFinalizer syncs the Namespaces a ClusterNamespaceLabel labeled before it is deleted
+const clusterNamespaceLabelFinalizer = "idandaniel.idandaniel.io/clusternamespacelabel-finalizer"
+
+type ClusterNamespaceLabelReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// NamespaceLabels syncs the labels of a Namespace with every resource labeling it, it must be set up first
+	NamespaceLabels *NamespaceLabelReconciler
+}
+
+//+kubebuilder:rbac:groups=idandaniel.idandaniel.io,resources=clusternamespacelabels,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=idandaniel.idandaniel.io,resources=clusternamespacelabels/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=idandaniel.idandaniel.io,resources=clusternamespacelabels/finalizers,verbs=update
+
+// Get the sorted names of the Namespaces selected by the ClusterNamespaceLabel
+func (r *ClusterNamespaceLabelReconciler) matchingNamespaces(ctx context.Context, clusterNamespaceLabel *idandanielv1.ClusterNamespaceLabel) ([]string, error) {
+	namespaceList := &corev1.NamespaceList{}
+	if err := r.List(ctx, namespaceList); err != nil {
+		return nil, err
+	}
+
+	var matched []string
+	for i := range namespaceList.Items {
+		matches, err := clusterNamespaceLabel.Spec.NamespaceSelector.Matches(&namespaceList.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			matched = append(matched, namespaceList.Items[i].GetName())
+		}
+	}
+
+	slices.Sort(matched)
+	return matched, nil
+}
+
+// Queue a sync of every given Namespace in the NamespaceLabel controller, which reconciles the Namespaces
+func (r *ClusterNamespaceLabelReconciler) syncNamespaces(ctx context.Context, namespaces []string) error {
+	for _, namespace := range namespaces {
+		if err := r.NamespaceLabels.enqueueNamespace(ctx, namespace); err != nil {
+			return fmt.Errorf("failed to queue the sync of namespace %s: %w", namespace, err)
+		}
+	}
+	return nil
+}
+
+// Handle ClusterNamespaceLabel deletion - sync the Namespaces it labeled without it
+func (r *ClusterNamespaceLabelReconciler) handleDeletion(ctx context.Context, clusterNamespaceLabel *idandanielv1.ClusterNamespaceLabel) error {
+	if !controllerutil.ContainsFinalizer(clusterNamespaceLabel, clusterNamespaceLabelFinalizer) {
+		return nil
+	}
+
+	ctrl.LoggerFrom(ctx).Info("Handling ClusterNamespaceLabel deletion")
+
+	// The sync ignores ClusterNamespaceLabels being deleted, and the ones which are gone
+	if err := r.syncNamespaces(ctx, clusterNamespaceLabel.Status.MatchedNamespaces); err != nil {
+		return err
+	}
+
+	controllerutil.RemoveFinalizer(clusterNamespaceLabel, clusterNamespaceLabelFinalizer)
+	return r.Update(ctx, clusterNamespaceLabel)
+}
+
+// Update the status of the ClusterNamespaceLabel with the outcome of the sync
+func (r *ClusterNamespaceLabelReconciler) updateStatus(ctx context.Context, clusterNamespaceLabel *idandanielv1.ClusterNamespaceLabel, matched []string, syncErr error) error {
+	original := clusterNamespaceLabel.DeepCopy()
+	status := &clusterNamespaceLabel.Status
+	status.ObservedGeneration = clusterNamespaceLabel.GetGeneration()
+
+	condition := metav1.Condition{
+		Type:               idandanielv1.ConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             idandanielv1.ReasonSyncQueued,
+		Message:            fmt.Sprintf("Labels are queued to sync with %d Namespaces", len(matched)),
+		ObservedGeneration: clusterNamespaceLabel.GetGeneration(),
+	}
+	if syncErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = idandanielv1.ReasonSyncFailed
+		condition.Message = syncErr.Error()
+	} else {
+		status.MatchedNamespaces = matched
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+
+	if equality.Semantic.DeepEqual(original.Status, clusterNamespaceLabel.Status) {
+		return nil
+	}
+	return r.Status().Patch(ctx, clusterNamespaceLabel, client.MergeFrom(original))
+}
+
+// Main reconcile loop
+func (r *ClusterNamespaceLabelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// The controller logger already carries the ClusterNamespaceLabel and the reconcile ID
+	logger := ctrl.LoggerFrom(ctx)
+
+	clusterNamespaceLabel := &idandanielv1.ClusterNamespaceLabel{}
+	if err := r.Get(ctx, req.NamespacedName, clusterNamespaceLabel); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "Failed to get ClusterNamespaceLabel")
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Handle finalizer
+	if clusterNamespaceLabel.IsBeingDeleted() {
+		return ctrl.Result{}, r.handleDeletion(ctx, clusterNamespaceLabel)
+	}
+	if !controllerutil.ContainsFinalizer(clusterNamespaceLabel, clusterNamespaceLabelFinalizer) {
+		controllerutil.AddFinalizer(clusterNamespaceLabel, clusterNamespaceLabelFinalizer)
+		if err := r.Update(ctx, clusterNamespaceLabel); err != nil {
+			logger.Error(err, "Failed to add finalizer to ClusterNamespaceLabel")
+			return ctrl.Result{}, err
+		}
+	}
+
+	matched, err := r.matchingNamespaces(ctx, clusterNamespaceLabel)
+	if err != nil {
+		logger.Error(err, "Failed to select Namespaces")
+		r.Recorder.Event(clusterNamespaceLabel, corev1.EventTypeWarning, idandanielv1.ReasonSyncFailed, err.Error())
+		return ctrl.Result{}, r.updateStatus(ctx, clusterNamespaceLabel, nil, err)
+	}
+
+	// Sync the Namespaces that match now, and the ones which stopped matching to remove the labels from them
+	namespaces := append([]string{}, matched...)
+	for _, namespace := range clusterNamespaceLabel.Status.MatchedNamespaces {
+		if !slices.Contains(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	logger.Info("Queueing the sync of the Namespaces", "Namespaces", namespaces, LabelsField, clusterNamespaceLabel.Spec.Labels)
+
+	syncErr := r.syncNamespaces(ctx, namespaces)
+	if syncErr != nil {
+		logger.Error(syncErr, "Failed to queue the sync of the Namespaces")
+		r.Recorder.Event(clusterNamespaceLabel, corev1.EventTypeWarning, idandanielv1.ReasonSyncFailed, syncErr.Error())
+	}
+	if err := r.updateStatus(ctx, clusterNamespaceLabel, matched, syncErr); err != nil {
+		logger.Error(err, "Failed to update ClusterNamespaceLabel status")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, syncErr
+}
+
+// Map a Namespace to the ClusterNamespaceLabels which select it or used to
+func (r *ClusterNamespaceLabelReconciler) clusterNamespaceLabelsForNamespace(namespace client.Object) []reconcile.Request {
+	clusterNamespaceLabelList := &idandanielv1.ClusterNamespaceLabelList{}
+	if err := r.List(context.Background(), clusterNamespaceLabelList); err != nil {
+		ctrl.Log.WithName("clusternamespacelabel").Error(err, "Failed to list ClusterNamespaceLabels", NamespaceField, namespace.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, clusterNamespaceLabel := range clusterNamespaceLabelList.Items {
+		matches, err := clusterNamespaceLabel.Spec.NamespaceSelector.Matches(namespace.(*corev1.Namespace))
+		if err != nil || (!matches && !slices.Contains(clusterNamespaceLabel.Status.MatchedNamespaces, namespace.GetName())) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: clusterNamespaceLabel.GetName()}})
+	}
+
+	return requests
+}
+
+// New Namespaces and label changes may change the Namespaces a ClusterNamespaceLabel selects
+func namespaceCreatedOrLabelsChanged() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc:  func(event.CreateEvent) bool { return true },
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return predicate.LabelChangedPredicate{}.Update(e)
+		},
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterNamespaceLabelReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.NamespaceLabels == nil || r.NamespaceLabels.namespaceEvents == nil {
+		return errors.New("the NamespaceLabel controller must be set up before the ClusterNamespaceLabel controller")
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&idandanielv1.ClusterNamespaceLabel{}).
+		Watches(
+			&source.Kind{Type: &corev1.Namespace{}},
+			handler.EnqueueRequestsFromMapFunc(r.clusterNamespaceLabelsForNamespace),
+			builder.WithPredicates(namespaceCreatedOrLabelsChanged()),
+		).
+		Complete(r)
+}
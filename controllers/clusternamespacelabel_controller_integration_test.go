@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("ClusterNamespaceLabel controller test", Ordered, func() {
+
+	ctx := context.Background()
+
+	Namespace := RandomString(16)
+	ClusterNamespaceLabelName := RandomString(16)
+	selectorLabels := map[string]string{
+		"team": Namespace,
+	}
+	clusterLabels := map[string]string{
+		"cost-center": "cc_1",
+	}
+	clusterNamespaceLabelLookupKey := types.NamespacedName{Name: ClusterNamespaceLabelName}
+
+	BeforeAll(func() {
+		createNamespace(ctx, Namespace, selectorLabels)
+	})
+
+	AfterAll(func() {
+		deleteNamespace(ctx, Namespace)
+	})
+
+	Context("When creating ClusterNamespaceLabel", func() {
+		It("Should sync the Labels with the selected Namespaces.", func() {
+			clusterNamespaceLabel := &idandanielv1.ClusterNamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: ClusterNamespaceLabelName,
+				},
+				Spec: idandanielv1.ClusterNamespaceLabelSpec{
+					NamespaceSelector: idandanielv1.NamespaceSelector{
+						LabelSelector: &metav1.LabelSelector{MatchLabels: selectorLabels},
+					},
+					Labels: clusterLabels,
+				},
+			}
+			Expect(k8sClient.Create(ctx, clusterNamespaceLabel)).Should(Succeed())
+
+			ensureLabelsExists(ctx, Namespace, clusterLabels)
+
+			Eventually(func() bool {
+				createdClusterNamespaceLabel := &idandanielv1.ClusterNamespaceLabel{}
+				Expect(k8sClient.Get(ctx, clusterNamespaceLabelLookupKey, createdClusterNamespaceLabel)).To(Not(HaveOccurred()))
+
+				return meta.IsStatusConditionTrue(createdClusterNamespaceLabel.Status.Conditions, idandanielv1.ConditionReady) &&
+					len(createdClusterNamespaceLabel.Status.MatchedNamespaces) == 1 &&
+					createdClusterNamespaceLabel.Status.MatchedNamespaces[0] == Namespace
+			}, Duration, Interval).Should(BeTrue())
+		})
+	})
+
+	Context("When the Namespace is no longer selected", func() {
+		It("Should remove the Labels from the Namespace.", func() {
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: Namespace}, namespace)).Should(Succeed())
+			namespace.Labels["team"] = "other"
+			Expect(k8sClient.Update(ctx, namespace)).Should(Succeed())
+
+			ensureLabelsDoesNotExist(ctx, Namespace, clusterLabels)
+		})
+	})
+
+	Context("When deleting ClusterNamespaceLabel", func() {
+		It("Should delete the Labels from the selected Namespaces.", func() {
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: Namespace}, namespace)).Should(Succeed())
+			namespace.Labels["team"] = Namespace
+			Expect(k8sClient.Update(ctx, namespace)).Should(Succeed())
+			ensureLabelsExists(ctx, Namespace, clusterLabels)
+
+			clusterNamespaceLabel := &idandanielv1.ClusterNamespaceLabel{}
+			Expect(k8sClient.Get(ctx, clusterNamespaceLabelLookupKey, clusterNamespaceLabel)).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, clusterNamespaceLabel)).Should(Succeed())
+
+			ensureLabelsDoesNotExist(ctx, Namespace, clusterLabels)
+		})
+	})
+})
@@ -0,0 +1,213 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+)
+
+// Changes a sync made to the labels or annotations of a Namespace, reported as events
+type metadataChanges struct {
+	// kind is the plural noun of the changed field used in the event messages
+	kind          string
+	addedReason   string
+	removedReason string
+	// added are the keys which were added or changed, with their new values
+	added map[string]string
+	// removed are the sorted keys which were removed
+	removed []string
+	// restored are the keys which were set back to the values they had before NamespaceLabels overrode them
+	restored map[string]string
+}
+
+func labelChanges(before map[string]string, after map[string]string) *metadataChanges {
+	return diffMetadata("labels", idandanielv1.ReasonLabelsAdded, idandanielv1.ReasonLabelsRemoved, before, after)
+}
+
+func annotationChanges(before map[string]string, after map[string]string) *metadataChanges {
+	return diffMetadata("annotations", idandanielv1.ReasonAnnotationsAdded, idandanielv1.ReasonAnnotationsRemoved, before, after)
+}
+
+func diffMetadata(kind string, addedReason string, removedReason string, before map[string]string, after map[string]string) *metadataChanges {
+	changes := &metadataChanges{
+		kind:          kind,
+		addedReason:   addedReason,
+		removedReason: removedReason,
+		added:         make(map[string]string),
+	}
+	for key, value := range after {
+		if previous, exists := before[key]; !exists || previous != value {
+			changes.added[key] = value
+		}
+	}
+	for _, key := range sortedKeys(before) {
+		if _, exists := after[key]; !exists {
+			changes.removed = append(changes.removed, key)
+		}
+	}
+	return changes
+}
+
+// Keep only the added keys the wanted values set, and the removed and restored keys the NamespaceLabel applied before
+// the sync, as the changes a single NamespaceLabel made
+func (c *metadataChanges) setBy(wanted map[string]string, applied map[string]string) *metadataChanges {
+	changes := &metadataChanges{
+		kind:          c.kind,
+		addedReason:   c.addedReason,
+		removedReason: c.removedReason,
+		added:         make(map[string]string),
+	}
+	for key, value := range wanted {
+		if added, exists := c.added[key]; exists && added == value {
+			changes.added[key] = value
+		}
+	}
+	for _, key := range c.removed {
+		if _, wasApplied := applied[key]; wasApplied {
+			changes.removed = append(changes.removed, key)
+		}
+	}
+	for key, value := range c.restored {
+		if _, wasApplied := applied[key]; wasApplied {
+			if changes.restored == nil {
+				changes.restored = make(map[string]string)
+			}
+			changes.restored[key] = value
+		}
+	}
+	return changes
+}
+
+// Report the restored keys as restored instead of removed
+func (c *metadataChanges) withRestored(restored map[string]string) *metadataChanges {
+	if len(restored) == 0 {
+		return c
+	}
+	c.restored = restored
+	removed := make([]string, 0, len(c.removed))
+	for _, key := range c.removed {
+		if _, isRestored := restored[key]; !isRestored {
+			removed = append(removed, key)
+		}
+	}
+	c.removed = removed
+	return c
+}
+
+// Get the values of the owned keys
+func ownedValues(values map[string]string, owned []string) map[string]string {
+	result := make(map[string]string, len(owned))
+	for _, key := range owned {
+		if value, exists := values[key]; exists {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// Get the changes computed in audit mode as reported in the NamespaceLabel status, nil when there are none
+func pendingChanges(labels *metadataChanges, annotations *metadataChanges) *idandanielv1.PendingChanges {
+	if len(labels.added) == 0 && len(labels.removed) == 0 && len(annotations.added) == 0 && len(annotations.removed) == 0 {
+		return nil
+	}
+	pending := &idandanielv1.PendingChanges{
+		RemovedLabels:      labels.removed,
+		RemovedAnnotations: annotations.removed,
+	}
+	if len(labels.added) > 0 {
+		pending.AddedLabels = labels.added
+	}
+	if len(annotations.added) > 0 {
+		pending.AddedAnnotations = annotations.added
+	}
+	return pending
+}
+
+func (c *metadataChanges) addedValues() string {
+	return joinValues(c.added)
+}
+
+func joinValues(m map[string]string) string {
+	values := make([]string, 0, len(m))
+	for _, key := range sortedKeys(m) {
+		values = append(values, fmt.Sprintf("%s=%s", key, m[key]))
+	}
+	return strings.Join(values, ", ")
+}
+
+// Emit a normal event on the object for the keys added to and removed from the Namespace
+func (r *NamespaceLabelReconciler) recordChanges(object runtime.Object, namespace string, changes *metadataChanges) {
+	if len(changes.added) > 0 {
+		r.Recorder.Eventf(object, corev1.EventTypeNormal, changes.addedReason, "Added %s to Namespace %s: %s", changes.kind, namespace, changes.addedValues())
+	}
+	if len(changes.removed) > 0 {
+		r.Recorder.Eventf(object, corev1.EventTypeNormal, changes.removedReason, "Removed %s from Namespace %s: %s", changes.kind, namespace, strings.Join(changes.removed, ", "))
+	}
+	if len(changes.restored) > 0 {
+		r.Recorder.Eventf(object, corev1.EventTypeNormal, idandanielv1.ReasonLabelsRestored, "Restored the previous values of %s on Namespace %s: %s", changes.kind, namespace, joinValues(changes.restored))
+	}
+}
+
+// Emit a normal event on the object for the keys audit mode would add to and remove from the Namespace
+func (r *NamespaceLabelReconciler) recordAudit(object runtime.Object, namespace string, changes *metadataChanges) {
+	if len(changes.added) > 0 {
+		r.Recorder.Eventf(object, corev1.EventTypeNormal, idandanielv1.ReasonAudit, "Would add %s to Namespace %s: %s", changes.kind, namespace, changes.addedValues())
+	}
+	if len(changes.removed) > 0 {
+		r.Recorder.Eventf(object, corev1.EventTypeNormal, idandanielv1.ReasonAudit, "Would remove %s from Namespace %s: %s", changes.kind, namespace, strings.Join(changes.removed, ", "))
+	}
+}
+
+// Emit a warning event on the NamespaceLabel and its Namespace for every label or annotation skipped since its previous status, and count it
+func (r *NamespaceLabelReconciler) recordSkipped(namespace *corev1.Namespace, namespaceLabel *idandanielv1.NamespaceLabel, kind string, skippedKeys []idandanielv1.SkippedLabel, previouslySkipped []idandanielv1.SkippedLabel) {
+	for _, skipped := range skippedKeys {
+		if slices.Contains(previouslySkipped, skipped) {
+			continue
+		}
+		r.Recorder.Eventf(namespaceLabel, corev1.EventTypeWarning, skipped.Reason, "%s %s was not applied: %s", kind, skipped.Key, skipped.Message)
+		r.Recorder.Eventf(namespace, corev1.EventTypeWarning, skipped.Reason, "%s %s of NamespaceLabel %s was not applied: %s", kind, skipped.Key, namespaceLabel.GetName(), skipped.Message)
+		observeSkipped(skipped.Reason)
+	}
+}
+
+// Emit a warning event on every object about a failed API call, an OwnershipConflict when other field managers own
+// the applied fields
+func (r *NamespaceLabelReconciler) recordFailure(err error, message string, objects ...runtime.Object) {
+	reason := idandanielv1.ReasonSyncFailed
+	if isApplyConflict(err) {
+		reason = idandanielv1.ReasonOwnershipConflict
+	}
+	for _, object := range objects {
+		r.Recorder.Eventf(object, corev1.EventTypeWarning, reason, "%s: %v", message, err)
+	}
+}
+
+// Get the NamespaceLabels of the list as event recipients
+func namespaceLabelObjects(namespaceLabels *idandanielv1.NamespaceLabelList) []runtime.Object {
+	objects := make([]runtime.Object, 0, len(namespaceLabels.Items))
+	for i := range namespaceLabels.Items {
+		objects = append(objects, &namespaceLabels.Items[i])
+	}
+	return objects
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+	"idandaniel.io/namespacelabel-demo/common/wrappers"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+)
+
+var _ = Describe("NamespaceLabel Events", func() {
+	const EventsNamespace = "events"
+
+	var recorder *record.FakeRecorder
+	var reconciler *NamespaceLabelReconciler
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: EventsNamespace}}
+	namespaceLabel := &idandanielv1.NamespaceLabel{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: namespace.Name},
+		Spec: idandanielv1.NamespaceLabelSpec{
+			Labels: map[string]string{"team": "a", "tier": "gold"},
+		},
+	}
+
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(10)
+		reconciler = &NamespaceLabelReconciler{Recorder: recorder}
+	})
+
+	It("Should compute the added, changed and removed keys", func() {
+		changes := labelChanges(
+			map[string]string{"team": "b", "tier": "gold", "old": "value"},
+			map[string]string{"team": "a", "tier": "gold", "new": "value"},
+		)
+
+		Expect(changes.added).Should(Equal(map[string]string{"team": "a", "new": "value"}))
+		Expect(changes.removed).Should(Equal([]string{"old"}))
+	})
+
+	It("Should report the changes on the Namespace and the NamespaceLabels which made them", func() {
+		changes := labelChanges(map[string]string{"old": "value"}, map[string]string{"team": "a", "other": "b"})
+
+		reconciler.recordChanges(namespace, namespace.Name, changes)
+		reconciler.recordChanges(namespaceLabel, namespace.Name, changes.setBy(namespaceLabel.Spec.Labels, nil))
+
+		Expect(receivedEvents(recorder)).Should(Equal([]string{
+			"Normal LabelsAdded Added labels to Namespace events: other=b, team=a",
+			"Normal LabelsRemoved Removed labels from Namespace events: old",
+			"Normal LabelsAdded Added labels to Namespace events: team=a",
+		}))
+	})
+
+	It("Should report the removed and restored keys on the NamespaceLabels which released them", func() {
+		changes := labelChanges(
+			map[string]string{"team": "a", "old": "value", "cost": "1234", "tier": "gold"},
+			map[string]string{"team": "a"},
+		).withRestored(map[string]string{"tier": "silver"})
+		released := namespaceLabel.DeepCopy()
+		released.Name = "released"
+		released.Spec.Labels = nil
+		released.Status.AppliedLabels = map[string]string{"old": "value", "tier": "gold"}
+
+		reconciler.recordChanges(namespaceLabel, namespace.Name, changes.setBy(namespaceLabel.Spec.Labels, map[string]string{"team": "a"}))
+		reconciler.recordChanges(released, namespace.Name, changes.setBy(released.Spec.Labels, released.Status.AppliedLabels))
+
+		Expect(receivedEvents(recorder)).Should(Equal([]string{
+			"Normal LabelsRemoved Removed labels from Namespace events: old",
+			"Normal LabelsRestored Restored the previous values of labels on Namespace events: tier=silver",
+		}))
+	})
+
+	It("Should report the labels a sync removed on the NamespaceLabel which released them", func() {
+		labels := map[string]string{"team": "a", "tier": "gold"}
+		annotations := map[string]string{wrappers.OwnedLabelsAnnotation: "team,tier"}
+		current := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:          EventsNamespace,
+			Labels:        labels,
+			Annotations:   annotations,
+			ManagedFields: []metav1.ManagedFieldsEntry{appliedEntry(FieldManager, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Labels: labels, Annotations: annotations}})},
+		}}
+		released := namespaceLabel.DeepCopy()
+		released.Finalizers = []string{finalizer}
+		released.Spec.Labels = map[string]string{"team": "a"}
+		released.Status.AppliedLabels = labels
+		syncing := newTestReconciler(current, released)
+		syncing.Client = &pruningClient{countingClient: countingClient{Client: syncing.Client}}
+
+		Expect(syncing.sync(context.Background(), EventsNamespace)).Should(Succeed())
+
+		Expect(receivedEvents(syncing.Recorder.(*record.FakeRecorder))).Should(Equal([]string{
+			"Normal LabelsRemoved Removed labels from Namespace events: tier",
+			"Normal LabelsRemoved Removed labels from Namespace events: tier",
+		}))
+	})
+
+	It("Should not report anything when nothing changed", func() {
+		reconciler.recordChanges(namespace, namespace.Name, annotationChanges(map[string]string{"a": "b"}, map[string]string{"a": "b"}))
+
+		Expect(receivedEvents(recorder)).Should(BeEmpty())
+	})
+
+	It("Should warn the NamespaceLabel and the Namespace about newly skipped labels", func() {
+		previous := []idandanielv1.SkippedLabel{{Key: "tier", Value: "gold", Reason: idandanielv1.ReasonPolicyDenied, Message: "denied"}}
+		skipped := append(previous, idandanielv1.SkippedLabel{Key: "team", Value: "a", Reason: idandanielv1.ReasonLabelProtected, Message: "protected"})
+
+		reconciler.recordSkipped(namespace, namespaceLabel, "Label", skipped, previous)
+
+		Expect(receivedEvents(recorder)).Should(Equal([]string{
+			"Warning LabelProtected Label team was not applied: protected",
+			"Warning LabelProtected Label team of NamespaceLabel team was not applied: protected",
+		}))
+	})
+
+	It("Should warn about failed API calls", func() {
+		stale := apierrors.NewConflict(schema.GroupResource{Resource: "namespaces"}, namespace.Name, errors.New("the object has been modified"))
+
+		reconciler.recordFailure(errors.New("timeout"), "Failed to get the Namespace", namespaceLabel)
+		reconciler.recordFailure(stale, "Failed to update", namespaceLabel)
+		reconciler.recordFailure(newApplyConflict(".metadata.labels.team"), "Failed to apply", namespaceLabel, namespace)
+
+		events := receivedEvents(recorder)
+		Expect(events).Should(HaveLen(4))
+		Expect(events[0]).Should(Equal("Warning SyncFailed Failed to get the Namespace: timeout"))
+		Expect(events[1]).Should(HavePrefix("Warning SyncFailed Failed to update: "))
+		Expect(events[2]).Should(HavePrefix("Warning OwnershipConflict Failed to apply: "))
+		Expect(events[3]).Should(Equal(events[2]))
+	})
+})
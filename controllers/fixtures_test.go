@@ -0,0 +1,305 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Build the scheme of the unit tests, with the Kubernetes types and the types of the API
+func newTestScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(idandanielv1.AddToScheme(scheme))
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	return scheme
+}
+
+// Build a fake client of the test scheme holding the objects
+func newFakeClient(objects ...client.Object) client.Client {
+	return fake.NewClientBuilder().WithScheme(newTestScheme()).WithObjects(objects...).Build()
+}
+
+// Build a reconciler reading the objects from a fake client and recording its events in a FakeRecorder
+func newTestReconciler(objects ...client.Object) *NamespaceLabelReconciler {
+	k8sClient := newFakeClient(objects...)
+	return &NamespaceLabelReconciler{
+		Client:   k8sClient,
+		Scheme:   k8sClient.Scheme(),
+		Recorder: record.NewFakeRecorder(20),
+	}
+}
+
+// Drain the events recorded so far
+func receivedEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case e := <-recorder.Events:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+// countingClient counts the writes sent to the API server. The fake client does not support server-side apply,
+// apply patches are sent as merge patches recording the applied keys of the field manager in the managed fields of
+// the object. The managed fields are replaced by that single apply entry, so no other field manager ever takes the
+// keys over, and the keys left out of an apply are never removed. The writes counted are the ones the reconciler
+// decides to send, not the ones an API server with field ownership would need.
+type countingClient struct {
+	client.Client
+	writes int
+}
+
+func (c *countingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.writes++
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *countingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.writes++
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *countingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	c.writes++
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *countingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.writes++
+	if patch.Type() != types.ApplyPatchType {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+
+	patchOptions := &client.PatchOptions{}
+	patchOptions.ApplyOptions(opts)
+	obj.SetManagedFields([]metav1.ManagedFieldsEntry{appliedEntry(patchOptions.FieldManager, obj)})
+	return c.Client.Patch(ctx, obj, client.Merge, client.FieldOwner(patchOptions.FieldManager))
+}
+
+// Build the managed fields entry of the field manager server-side applying the labels and annotations of the object
+func appliedEntry(fieldManager string, obj client.Object) metav1.ManagedFieldsEntry {
+	metadata := make(map[string]interface{})
+	for field, values := range map[string]map[string]string{"f:labels": obj.GetLabels(), "f:annotations": obj.GetAnnotations()} {
+		if len(values) == 0 {
+			continue
+		}
+		keys := make(map[string]interface{}, len(values))
+		for key := range values {
+			keys["f:"+key] = struct{}{}
+		}
+		metadata[field] = keys
+	}
+	raw, _ := json.Marshal(map[string]interface{}{"f:metadata": metadata})
+	return metav1.ManagedFieldsEntry{
+		Manager:    fieldManager,
+		Operation:  metav1.ManagedFieldsOperationApply,
+		FieldsV1:   &metav1.FieldsV1{Raw: raw},
+		FieldsType: "FieldsV1",
+	}
+}
+
+func (c *countingClient) Status() client.StatusWriter {
+	return &countingStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+// countingStatusWriter counts the status writes in its countingClient
+type countingStatusWriter struct {
+	client.StatusWriter
+	client *countingClient
+}
+
+func (w *countingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	w.client.writes++
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func (w *countingStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	w.client.writes++
+	return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
+
+// conflictingClient fails the server-side apply of labels managed by others with a different value, like the API server
+// does without ForceOwnership. A forced apply takes the labels over from the other field managers.
+type conflictingClient struct {
+	countingClient
+	// managedByOthers are the labels other field managers set
+	managedByOthers map[string]string
+}
+
+func (c *conflictingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	patchOptions := &client.PatchOptions{}
+	patchOptions.ApplyOptions(opts)
+	if patch.Type() == types.ApplyPatchType && patchOptions.Force != nil && *patchOptions.Force {
+		for key := range obj.GetLabels() {
+			delete(c.managedByOthers, key)
+		}
+	}
+	if patch.Type() == types.ApplyPatchType {
+		var fields []string
+		for key, value := range obj.GetLabels() {
+			if other, exists := c.managedByOthers[key]; exists && other != value {
+				fields = append(fields, ".metadata.labels."+key)
+			}
+		}
+		if len(fields) > 0 {
+			return newApplyConflict(fields...)
+		}
+	}
+	return c.countingClient.Patch(ctx, obj, patch, opts...)
+}
+
+// Build the error of a server-side apply conflicting on the fields
+func newApplyConflict(fields ...string) error {
+	causes := make([]metav1.StatusCause, 0, len(fields))
+	for _, field := range fields {
+		causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldManagerConflict, Message: `conflict with "kubectl-label"`, Field: field})
+	}
+	return &apierrors.StatusError{ErrStatus: metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusConflict,
+		Reason:  metav1.StatusReasonConflict,
+		Details: &metav1.StatusDetails{Name: "ownership-conflict", Kind: "namespaces", Causes: causes},
+		Message: "Apply failed with conflicts",
+	}}
+}
+
+// failingApplyClient fails every server-side apply, the other writes are sent to its countingClient
+type failingApplyClient struct {
+	countingClient
+}
+
+func (c *failingApplyClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() == types.ApplyPatchType {
+		return apierrors.NewServiceUnavailable("the server is currently unable to handle the apply")
+	}
+	return c.countingClient.Patch(ctx, obj, patch, opts...)
+}
+
+// pruningClient removes the labels and annotations a server-side apply gives up, like the API server does for the keys
+// no other field manager set: the keys of the previous apply missing from the new one are removed, unless a merge patch
+// set them since
+type pruningClient struct {
+	countingClient
+	// patched are the metadata fields set by the merge patches since the last apply, e.g. "labels/team"
+	patched map[string]bool
+}
+
+func (c *pruningClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		data, err := patch.Data(obj)
+		if err != nil {
+			return err
+		}
+		patched := &struct {
+			Metadata map[string]map[string]interface{} `json:"metadata"`
+		}{}
+		if err := json.Unmarshal(data, patched); err != nil {
+			return err
+		}
+		if c.patched == nil {
+			c.patched = make(map[string]bool)
+		}
+		for field, values := range patched.Metadata {
+			for key := range values {
+				c.patched[field+"/"+key] = true
+			}
+		}
+		return c.countingClient.Patch(ctx, obj, patch, opts...)
+	}
+
+	current := &corev1.Namespace{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(obj), current); err != nil {
+		return err
+	}
+	pruned := map[string]map[string]interface{}{"labels": {}, "annotations": {}}
+	for _, entry := range current.ManagedFields {
+		if entry.Operation != metav1.ManagedFieldsOperationApply || entry.FieldsV1 == nil {
+			continue
+		}
+		applied := &struct {
+			Metadata map[string]map[string]interface{} `json:"f:metadata"`
+		}{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, applied); err != nil {
+			return err
+		}
+		for field, values := range map[string]map[string]string{"labels": obj.GetLabels(), "annotations": obj.GetAnnotations()} {
+			for key := range applied.Metadata["f:"+field] {
+				key = strings.TrimPrefix(key, "f:")
+				if _, isApplied := values[key]; !isApplied && !c.patched[field+"/"+key] {
+					pruned[field][key] = nil
+				}
+			}
+		}
+	}
+	c.patched = nil
+
+	if err := c.countingClient.Patch(ctx, obj, patch, opts...); err != nil {
+		return err
+	}
+	data, err := json.Marshal(map[string]interface{}{"metadata": pruned})
+	if err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, client.RawPatch(types.MergePatchType, data))
+}
+
+// indexingClient lists through field indexes like the manager cache does, the fake client ignores the field selectors:
+// only the objects the indexes of the selected fields return the selected values for are listed, and selecting a field
+// without an index fails
+type indexingClient struct {
+	client.Client
+	// indexes are the indexers of the fields which can be selected, keyed by field
+	indexes map[string]client.IndexerFunc
+}
+
+func (c *indexingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOptions := &client.ListOptions{}
+	listOptions.ApplyOptions(opts)
+	if listOptions.FieldSelector == nil {
+		return c.Client.List(ctx, list, opts...)
+	}
+	requirements := listOptions.FieldSelector.Requirements()
+	for _, requirement := range requirements {
+		if _, exists := c.indexes[requirement.Field]; !exists {
+			return fmt.Errorf("index with name field:%s does not exist", requirement.Field)
+		}
+	}
+
+	listOptions.FieldSelector = nil
+	if err := c.Client.List(ctx, list, listOptions); err != nil {
+		return err
+	}
+	objects, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	var indexed []runtime.Object
+	for _, object := range objects {
+		matches := true
+		for _, requirement := range requirements {
+			matches = matches && slices.Contains(c.indexes[requirement.Field](object.(client.Object)), requirement.Value)
+		}
+		if matches {
+			indexed = append(indexed, object)
+		}
+	}
+	return meta.SetList(list, indexed)
+}
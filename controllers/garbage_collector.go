@@ -0,0 +1,216 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/maps"
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+	"idandaniel.io/namespacelabel-demo/common/wrappers"
+)
+
+// GarbageCollector removes the labels and annotations left on Namespaces by NamespaceLabels which were deleted
+// without their finalizer, e.g. when the finalizer was stripped or the operator was down during the deletion.
+// It collects once at startup and then every Interval.
+type GarbageCollector struct {
+	// NamespaceLabels computes and applies the labels of the Namespaces
+	NamespaceLabels *NamespaceLabelReconciler
+	// Interval between two collections, zero only collects at startup
+	Interval time.Duration
+}
+
+var _ manager.Runnable = &GarbageCollector{}
+var _ manager.LeaderElectionRunnable = &GarbageCollector{}
+
+// ownedKeysIndex indexes the Namespaces having labels or annotations owned by NamespaceLabels, the only ones collected
+const ownedKeysIndex = "metadata.ownedKeys"
+
+// Index a Namespace under true when NamespaceLabels own some of its labels or annotations
+func indexOwnedKeys(object client.Object) []string {
+	annotations := object.GetAnnotations()
+	if annotations[wrappers.OwnedLabelsAnnotation] == "" && annotations[wrappers.OwnedAnnotationsAnnotation] == "" {
+		return nil
+	}
+	return []string{"true"}
+}
+
+// SetupWithManager indexes the Namespaces by their owned keys and adds the GarbageCollector to the Manager
+func (gc *GarbageCollector) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &corev1.Namespace{}, ownedKeysIndex, indexOwnedKeys); err != nil {
+		return err
+	}
+	return mgr.Add(gc)
+}
+
+// Orphaned labels and annotations found on a Namespace
+type orphans struct {
+	labels      []string
+	annotations []string
+}
+
+func (o *orphans) isEmpty() bool {
+	return len(o.labels) == 0 && len(o.annotations) == 0
+}
+
+// Start collects the orphaned labels and annotations until the context is done
+func (gc *GarbageCollector) Start(ctx context.Context) error {
+	ctx = ctrl.LoggerInto(ctx, ctrl.Log.WithName("garbage-collector"))
+	if gc.Interval <= 0 {
+		gc.collect(ctx)
+		<-ctx.Done()
+		return nil
+	}
+
+	wait.UntilWithContext(ctx, gc.collect, gc.Interval)
+	return nil
+}
+
+// NeedLeaderElection makes only the leader collect
+func (gc *GarbageCollector) NeedLeaderElection() bool {
+	return true
+}
+
+// Collect the orphaned labels and annotations of every Namespace with owned keys, the failures are logged and retried
+// on the next collection
+func (gc *GarbageCollector) collect(ctx context.Context) {
+	logger := ctrl.LoggerFrom(ctx)
+	r := gc.NamespaceLabels
+
+	namespaceList := &corev1.NamespaceList{}
+	if err := r.List(ctx, namespaceList, client.MatchingFields{ownedKeysIndex: "true"}); err != nil {
+		logger.Error(err, "Failed to list Namespaces")
+		return
+	}
+
+	var errs []error
+	collected := 0
+	for i := range namespaceList.Items {
+		namespace := &namespaceList.Items[i]
+		namespaceCtx := ctrl.LoggerInto(ctx, logger.WithValues(NamespaceField, namespace.GetName()))
+		removed, err := gc.collectNamespace(namespaceCtx, namespace)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("namespace %s: %w", namespace.GetName(), err))
+			continue
+		}
+		if !removed.isEmpty() {
+			collected++
+		}
+	}
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		logger.Error(err, "Failed to collect orphaned labels")
+	}
+	logger.Info("Collected orphaned labels", "namespaces", collected)
+}
+
+// Remove the orphaned labels and annotations of the Namespace, returning what was removed
+func (gc *GarbageCollector) collectNamespace(ctx context.Context, namespace *corev1.Namespace) (*orphans, error) {
+	logger := ctrl.LoggerFrom(ctx)
+	r := gc.NamespaceLabels
+
+	// A paused Namespace is left as it is
+	if idandanielv1.IsPaused(namespace) {
+		return &orphans{}, nil
+	}
+
+	wrappedNamespace, removed, err := gc.withoutOrphans(ctx, namespace)
+	if err != nil || removed.isEmpty() {
+		return removed, err
+	}
+
+	changedLabels := labelChanges(ownedValues(namespace.Labels, removed.labels), nil).withRestored(wrappedNamespace.RestoredLabels())
+	changedAnnotations := annotationChanges(ownedValues(namespace.Annotations, removed.annotations), nil)
+	if r.DryRun {
+		r.recordAudit(namespace, namespace.GetName(), changedLabels)
+		r.recordAudit(namespace, namespace.GetName(), changedAnnotations)
+		return removed, nil
+	}
+
+	if _, err := r.applyNamespace(ctx, namespace, wrappedNamespace); err != nil {
+		r.recordFailure(err, "Failed to remove the orphaned labels and annotations", namespace)
+		return nil, client.IgnoreNotFound(err)
+	}
+
+	logger.Info("Removed orphaned labels and annotations", LabelsField, removed.labels, AnnotationsField, removed.annotations)
+	var keys []string
+	keys = append(keys, removed.labels...)
+	keys = append(keys, removed.annotations...)
+	r.Recorder.Eventf(namespace, corev1.EventTypeNormal, idandanielv1.ReasonOrphansRemoved,
+		"Removed the labels and annotations no NamespaceLabel declares anymore from Namespace %s: %s", namespace.GetName(), strings.Join(keys, ", "))
+	r.recordChanges(namespace, namespace.GetName(), (&metadataChanges{kind: "labels"}).withRestored(wrappedNamespace.RestoredLabels()))
+	observeOrphans(removed)
+	observeLabelChanges(namespace.GetName(), changedLabels, len(wrappedNamespace.OwnedLabels()))
+	return removed, nil
+}
+
+// Compute the Namespace without the owned labels and annotations no NamespaceLabel or ClusterNamespaceLabel declares,
+// returning the updated copy of the Namespace which is not written and the orphaned keys
+func (gc *GarbageCollector) withoutOrphans(ctx context.Context, namespace *corev1.Namespace) (*wrappers.NamespaceWrapper, *orphans, error) {
+	r := gc.NamespaceLabels
+	wrappedNamespace := r.wrapNamespace(namespace.DeepCopy())
+	removed := &orphans{}
+	if len(wrappedNamespace.OwnedLabels()) == 0 && len(wrappedNamespace.OwnedAnnotations()) == 0 {
+		return wrappedNamespace, removed, nil
+	}
+
+	// The labels of the sources and the rendered templates are declared as well
+	namespaceLabelList := &idandanielv1.NamespaceLabelList{}
+	if err := r.List(ctx, namespaceLabelList, client.InNamespace(namespace.GetName())); err != nil {
+		return nil, nil, err
+	}
+	resolved, _, _, err := r.resolveLabels(ctx, namespace, namespaceLabelList)
+	if err != nil {
+		return nil, nil, err
+	}
+	clusterLabels, _, err := r.clusterLabels(ctx, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	declaredLabels := maps.Clone(clusterLabels)
+	if declaredLabels == nil {
+		declaredLabels = make(map[string]string)
+	}
+	declaredAnnotations := make(map[string]string)
+	for _, namespaceLabel := range resolved.Items {
+		maps.Copy(declaredLabels, namespaceLabel.Spec.Labels)
+		maps.Copy(declaredAnnotations, namespaceLabel.Spec.Annotations)
+	}
+
+	for _, key := range wrappedNamespace.OwnedLabels() {
+		if _, isDeclared := declaredLabels[key]; !isDeclared {
+			wrappedNamespace.RemoveLabel(key, namespace.Labels[key])
+			removed.labels = append(removed.labels, key)
+		}
+	}
+	for _, key := range wrappedNamespace.OwnedAnnotations() {
+		if _, isDeclared := declaredAnnotations[key]; !isDeclared {
+			wrappedNamespace.RemoveAnnotation(key, namespace.Annotations[key])
+			removed.annotations = append(removed.annotations, key)
+		}
+	}
+	return wrappedNamespace, removed, nil
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+	"idandaniel.io/namespacelabel-demo/common/wrappers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Garbage Collector", func() {
+	const OrphansNamespace = "orphans"
+
+	ctx := context.Background()
+
+	newCollector := func(dryRun bool, recorder record.EventRecorder, namespaces ...client.Object) *GarbageCollector {
+		reconciler := newTestReconciler(append([]client.Object{
+			&idandanielv1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: "live", Namespace: OrphansNamespace},
+				Spec: idandanielv1.NamespaceLabelSpec{
+					Labels:      map[string]string{"team": "payments"},
+					Annotations: map[string]string{"owner": "alice"},
+				},
+			},
+			&idandanielv1.ClusterNamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec:       idandanielv1.ClusterNamespaceLabelSpec{Labels: map[string]string{"env": "prod"}},
+			},
+		}, namespaces...)...)
+		reconciler.Recorder = recorder
+		reconciler.DryRun = dryRun
+		return &GarbageCollector{NamespaceLabels: reconciler}
+	}
+	newNamespace := func() *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:   OrphansNamespace,
+			Labels: map[string]string{"team": "payments", "env": "prod", "tier": "gold", "cost": "1234", "manual": "true"},
+			Annotations: map[string]string{
+				"owner":                             "alice",
+				"contact":                           "bob",
+				wrappers.OwnedLabelsAnnotation:      "cost,env,team,tier",
+				wrappers.OwnedAnnotationsAnnotation: "contact,owner",
+				wrappers.PreviousLabelsAnnotation:   `{"tier":"silver"}`,
+			},
+		}}
+	}
+
+	It("Should remove the owned keys no NamespaceLabel or ClusterNamespaceLabel declares", func() {
+		wrappedNamespace, removed, err := newCollector(false, nil).withoutOrphans(ctx, newNamespace())
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(removed.labels).Should(Equal([]string{"cost", "tier"}))
+		Expect(removed.annotations).Should(Equal([]string{"contact"}))
+		Expect(wrappedNamespace.Labels).Should(Equal(map[string]string{"team": "payments", "env": "prod", "tier": "silver", "manual": "true"}))
+		Expect(wrappedNamespace.OwnedLabels()).Should(Equal([]string{"env", "team"}))
+		Expect(wrappedNamespace.OwnedAnnotations()).Should(Equal([]string{"owner"}))
+		Expect(wrappedNamespace.RestoredLabels()).Should(Equal(map[string]string{"tier": "silver"}))
+	})
+
+	It("Should only report the orphaned keys in dry-run", func() {
+		recorder := record.NewFakeRecorder(10)
+		removed, err := newCollector(true, recorder).collectNamespace(ctx, newNamespace())
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(removed.labels).Should(Equal([]string{"cost", "tier"}))
+		Expect(recorder.Events).Should(Receive(Equal("Normal Audit Would remove labels from Namespace " + OrphansNamespace + ": cost")))
+		Expect(recorder.Events).Should(Receive(Equal("Normal Audit Would remove annotations from Namespace " + OrphansNamespace + ": contact")))
+	})
+
+	It("Should only index the Namespaces with owned keys", func() {
+		Expect(indexOwnedKeys(newNamespace())).Should(Equal([]string{"true"}))
+		Expect(indexOwnedKeys(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: OrphansNamespace}})).Should(BeEmpty())
+	})
+
+	It("Should collect the Namespaces listed through the owned keys index", func() {
+		namespace := newNamespace()
+		applied := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Labels:      map[string]string{"team": "payments", "env": "prod", "tier": "gold", "cost": "1234"},
+			Annotations: map[string]string{"owner": "alice", "contact": "bob"},
+		}}
+		namespace.ManagedFields = []metav1.ManagedFieldsEntry{appliedEntry(FieldManager, applied)}
+		unowned := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "unowned", Labels: map[string]string{"manual": "true"}}}
+
+		recorder := record.NewFakeRecorder(10)
+		gc := newCollector(false, recorder, namespace, unowned)
+		gc.NamespaceLabels.Client = &indexingClient{
+			Client:  &pruningClient{countingClient: countingClient{Client: gc.NamespaceLabels.Client}},
+			indexes: map[string]client.IndexerFunc{ownedKeysIndex: indexOwnedKeys},
+		}
+		gc.collect(ctx)
+
+		collected := &corev1.Namespace{}
+		Expect(gc.NamespaceLabels.Get(ctx, client.ObjectKeyFromObject(namespace), collected)).Should(Succeed())
+		Expect(collected.Labels).Should(Equal(map[string]string{"team": "payments", "env": "prod", "tier": "silver", "manual": "true"}))
+		Expect(collected.Annotations).ShouldNot(HaveKey("contact"))
+		Expect(collected.Annotations).Should(HaveKeyWithValue("owner", "alice"))
+		Expect(receivedEvents(recorder)).Should(ConsistOf(
+			"Normal OrphansRemoved Removed the labels and annotations no NamespaceLabel declares anymore from Namespace "+OrphansNamespace+": cost, tier, contact",
+			"Normal LabelsRestored Restored the previous values of labels on Namespace "+OrphansNamespace+": tier=silver",
+		))
+	})
+
+	It("Should not collect anything without the owned keys index", func() {
+		recorder := record.NewFakeRecorder(10)
+		gc := newCollector(false, recorder, newNamespace())
+		gc.NamespaceLabels.Client = &indexingClient{Client: gc.NamespaceLabels.Client}
+		gc.collect(ctx)
+
+		namespace := &corev1.Namespace{}
+		Expect(gc.NamespaceLabels.Get(ctx, client.ObjectKeyFromObject(newNamespace()), namespace)).Should(Succeed())
+		Expect(namespace.Labels).Should(Equal(newNamespace().Labels))
+		Expect(recorder.Events).ShouldNot(Receive())
+	})
+
+	It("Should leave the Namespaces without owned keys", func() {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: OrphansNamespace, Labels: map[string]string{"manual": "true"}}}
+		removed, err := newCollector(false, nil).collectNamespace(ctx, namespace)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(removed.isEmpty()).Should(BeTrue())
+	})
+})
@@ -0,0 +1,145 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+)
+
+const (
+	syncResultSuccess = "success"
+	syncResultError   = "error"
+)
+
+var (
+	syncTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "namespacelabel_sync_total",
+		Help: "Number of Namespace syncs by result",
+	}, []string{"result"})
+
+	syncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "namespacelabel_sync_duration_seconds",
+		Help:    "Duration of the Namespace syncs by result",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"result"})
+
+	labelsAdded = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "namespacelabel_labels_added_total",
+		Help: "Number of labels added to or changed on Namespaces",
+	})
+
+	labelsRemoved = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "namespacelabel_labels_removed_total",
+		Help: "Number of labels removed from Namespaces",
+	})
+
+	conflictsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "namespacelabel_conflicts_total",
+		Help: "Number of label and annotation conflicts detected by reason",
+	}, []string{"reason"})
+
+	driftCorrections = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "namespacelabel_drift_corrections_total",
+		Help: "Number of applied labels and annotations removed or changed by others and set back on Namespaces",
+	})
+
+	protectedRejections = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "namespacelabel_protected_rejections_total",
+		Help: "Number of protected labels and annotations NamespaceLabels tried to set",
+	})
+
+	orphansRemoved = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "namespacelabel_orphans_removed_total",
+		Help: "Number of labels and annotations no NamespaceLabel declares anymore removed from Namespaces",
+	})
+
+	managedLabels = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "namespacelabel_managed_labels",
+		Help: "Number of labels managed by NamespaceLabels on a Namespace",
+	}, []string{"namespace"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		syncTotal,
+		syncDuration,
+		labelsAdded,
+		labelsRemoved,
+		conflictsTotal,
+		driftCorrections,
+		protectedRejections,
+		orphansRemoved,
+		managedLabels,
+	)
+}
+
+// Count a finished sync and observe its duration
+func observeSync(start time.Time, err error) {
+	result := syncResultSuccess
+	if err != nil {
+		result = syncResultError
+	}
+	syncTotal.WithLabelValues(result).Inc()
+	syncDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+}
+
+// Count the labels a sync changed and set the number of labels managed on the Namespace. Only the gauge is labeled
+// by Namespace, the counters would grow a series for every Namespace ever synced.
+func observeLabelChanges(namespace string, changes *metadataChanges, managed int) {
+	labelsAdded.Add(float64(len(changes.added)))
+	labelsRemoved.Add(float64(len(changes.removed)))
+	if managed == 0 {
+		managedLabels.DeleteLabelValues(namespace)
+		return
+	}
+	managedLabels.WithLabelValues(namespace).Set(float64(managed))
+}
+
+// Count a newly skipped label or annotation as a conflict or a protected key rejection according to its reason
+func observeSkipped(reason string) {
+	switch reason {
+	case idandanielv1.ReasonLabelProtected:
+		protectedRejections.Inc()
+	case idandanielv1.ReasonLabelConflict, idandanielv1.ReasonLabelOverridden, idandanielv1.ReasonConflictRefused, idandanielv1.ReasonOwnershipConflict:
+		conflictsTotal.WithLabelValues(reason).Inc()
+	}
+}
+
+// Count the orphaned labels and annotations removed from a Namespace
+func observeOrphans(removed *orphans) {
+	orphansRemoved.Add(float64(len(removed.labels) + len(removed.annotations)))
+}
+
+// Count the keys the NamespaceLabels of a Namespace report as applied which others removed or changed on the Namespace
+// and the sync set back, once per key even when several NamespaceLabels report it
+func observeDrift(applied []map[string]string, before map[string]string, after map[string]string) {
+	corrected := make(map[string]bool)
+	for _, values := range applied {
+		for key, value := range values {
+			previous, existed := before[key]
+			if current, exists := after[key]; (!existed || previous != value) && exists && current == value {
+				corrected[key] = true
+			}
+		}
+	}
+	driftCorrections.Add(float64(len(corrected)))
+}
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+)
+
+var _ = Describe("NamespaceLabel Metrics", func() {
+	const MetricsNamespace = "metrics-namespace"
+
+	It("Should count the syncs by result", func() {
+		succeeded := testutil.ToFloat64(syncTotal.WithLabelValues(syncResultSuccess))
+		failed := testutil.ToFloat64(syncTotal.WithLabelValues(syncResultError))
+
+		observeSync(time.Now(), nil)
+		observeSync(time.Now(), errors.New("sync failed"))
+		observeSync(time.Now(), errors.New("sync failed"))
+
+		Expect(testutil.ToFloat64(syncTotal.WithLabelValues(syncResultSuccess))).Should(Equal(succeeded + 1))
+		Expect(testutil.ToFloat64(syncTotal.WithLabelValues(syncResultError))).Should(Equal(failed + 2))
+	})
+
+	It("Should count the label changes and track the managed labels", func() {
+		added := testutil.ToFloat64(labelsAdded)
+		removed := testutil.ToFloat64(labelsRemoved)
+
+		observeLabelChanges(MetricsNamespace, labelChanges(
+			map[string]string{"team": "b", "old": "value"},
+			map[string]string{"team": "a", "tier": "gold"},
+		), 2)
+
+		Expect(testutil.ToFloat64(labelsAdded)).Should(Equal(added + 2))
+		Expect(testutil.ToFloat64(labelsRemoved)).Should(Equal(removed + 1))
+		Expect(testutil.ToFloat64(managedLabels.WithLabelValues(MetricsNamespace))).Should(Equal(float64(2)))
+
+		observeLabelChanges(MetricsNamespace, labelChanges(map[string]string{"team": "a", "tier": "gold"}, nil), 0)
+
+		Expect(testutil.ToFloat64(labelsRemoved)).Should(Equal(removed + 3))
+		Expect(managedLabels.DeleteLabelValues(MetricsNamespace)).Should(BeFalse())
+	})
+
+	It("Should count conflicts and protected keys", func() {
+		overridden := testutil.ToFloat64(conflictsTotal.WithLabelValues(idandanielv1.ReasonLabelOverridden))
+		rejected := testutil.ToFloat64(protectedRejections)
+
+		observeSkipped(idandanielv1.ReasonLabelOverridden)
+		observeSkipped(idandanielv1.ReasonLabelProtected)
+		observeSkipped(idandanielv1.ReasonPolicyDenied)
+
+		Expect(testutil.ToFloat64(conflictsTotal.WithLabelValues(idandanielv1.ReasonLabelOverridden))).Should(Equal(overridden + 1))
+		Expect(testutil.ToFloat64(protectedRejections)).Should(Equal(rejected + 1))
+	})
+
+	It("Should count the applied keys set back after others changed them", func() {
+		corrected := testutil.ToFloat64(driftCorrections)
+
+		observeDrift(
+			[]map[string]string{{"team": "a", "tier": "gold", "env": "prod"}},
+			map[string]string{"team": "b", "env": "prod"},
+			map[string]string{"team": "a", "tier": "gold", "env": "prod"},
+		)
+
+		Expect(testutil.ToFloat64(driftCorrections)).Should(Equal(corrected + 2))
+	})
+
+	It("Should count a key set back once when several NamespaceLabels applied it", func() {
+		corrected := testutil.ToFloat64(driftCorrections)
+
+		observeDrift(
+			[]map[string]string{{"team": "a", "tier": "gold"}, {"team": "a"}, {"tier": "silver"}},
+			map[string]string{"team": "b"},
+			map[string]string{"team": "a", "tier": "gold"},
+		)
+
+		Expect(testutil.ToFloat64(driftCorrections)).Should(Equal(corrected + 2))
+	})
+})
@@ -18,25 +18,71 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
-	"github.com/sirupsen/logrus"
-	"go.elastic.co/ecslogrus"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+	"idandaniel.io/namespacelabel-demo/common/protected"
 	"idandaniel.io/namespacelabel-demo/common/wrappers"
 )
 
 type NamespaceLabelReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// SourceReader reads the ConfigMaps of the label sources, only their metadata is cached. Defaults to the Client.
+	SourceReader client.Reader
+	// ProtectedLabels are the labels NamespaceLabels may never change, defaults to the Kubernetes labels
+	ProtectedLabels *protected.Rules
+	// ProtectedAnnotations are the annotations NamespaceLabels may never change, defaults to the kubectl annotations
+	ProtectedAnnotations *protected.Rules
+	// ForceOwnership takes over Namespace labels managed by others instead of reporting a conflict
+	ForceOwnership bool
+	// DryRun audits every NamespaceLabel, the Namespaces are never changed
+	DryRun bool
+	// ResyncPeriod is how often a NamespaceLabel is synced again when nothing changed, zero only syncs on changes.
+	// The ResyncInterval of a NamespaceLabel overrides it.
+	ResyncPeriod time.Duration
+	// namespaceEvents queues the syncs of Namespaces requested by other controllers
+	namespaceEvents chan event.GenericEvent
 }
 
+// FieldManager is the server-side apply field manager of the Namespace labels and annotations
+const FieldManager = "namespacelabel-controller"
+
+const (
+	// resyncJitter is the largest fraction of the resync interval added to it, so the NamespaceLabels created
+	// together are not synced all at once
+	resyncJitter = 0.1
+	// lastSyncTimeGranularity is the shortest time between two updates of the last sync time, the status updates
+	// of the syncs happening in between would only trigger more syncs
+	lastSyncTimeGranularity = time.Minute
+)
+
 const (
 	finalizer       string = "idandaniel.idandaniel.io/finalizer"
 	AddFinalizer    string = "ADD"
@@ -47,27 +93,59 @@ const (
 	NamespaceField      = "Namespace"
 	NamespaceLabelField = "NamespaceLabel"
 	LabelsField         = "Labels"
-)
+	AnnotationsField    = "Annotations"
 
-var log = logrus.New()
-
-func init() {
-	log.SetFormatter(&ecslogrus.Formatter{})
-}
+	NamespaceLabelPolicyField = "NamespaceLabelPolicy"
+	LabelsSourceField         = "LabelsSource"
+)
 
 //+kubebuilder:rbac:groups=idandaniel.idandaniel.io,resources=namespacelabels,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=idandaniel.idandaniel.io,resources=namespacelabels/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=idandaniel.idandaniel.io,resources=namespacelabels/finalizers,verbs=update
+//+kubebuilder:rbac:groups=idandaniel.idandaniel.io,resources=namespacelabelpolicies,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+
+func (r *NamespaceLabelReconciler) sourceReader() client.Reader {
+	if r.SourceReader == nil {
+		return r.Client
+	}
+	return r.SourceReader
+}
+
+func (r *NamespaceLabelReconciler) protectedLabels() *protected.Rules {
+	if r.ProtectedLabels == nil {
+		return protected.DefaultRules()
+	}
+	return r.ProtectedLabels
+}
+
+func (r *NamespaceLabelReconciler) protectedAnnotations() *protected.Rules {
+	if r.ProtectedAnnotations == nil {
+		return protected.DefaultAnnotationRules()
+	}
+	return r.ProtectedAnnotations
+}
+
+func (r *NamespaceLabelReconciler) wrapNamespace(namespace *corev1.Namespace) *wrappers.NamespaceWrapper {
+	return &wrappers.NamespaceWrapper{
+		Namespace:            namespace,
+		ProtectedLabels:      r.protectedLabels(),
+		ProtectedAnnotations: r.protectedAnnotations(),
+	}
+}
 
-// Handles removing safely NamespaceLabels labels from the associated Namespace labels when being deleted.
+// Handles removing safely NamespaceLabels labels and annotations from the associated Namespace when being deleted.
 func (r *NamespaceLabelReconciler) removeLabelsFromAssociatedNamespace(ctx context.Context, namespaceLabel *idandanielv1.NamespaceLabel) error {
-	log.WithFields(logrus.Fields{
-		NamespaceLabelField: namespaceLabel.GetName(),
-		NamespaceField:      namespaceLabel.GetNamespace(),
-		LabelsField:         namespaceLabel.Spec.Labels,
-	}).Info("Removing NamespaceLabel's Labels from Namespace")
-	labelsToRemove := namespaceLabel.Spec.Labels
+	logger := ctrl.LoggerFrom(ctx)
+	logger.Info("Removing NamespaceLabel's Labels from Namespace", LabelsField, namespaceLabel.Spec.Labels, AnnotationsField, namespaceLabel.Spec.Annotations)
+
+	// Get the namespace to remove labels from
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: namespaceLabel.GetNamespace()}, namespace); err != nil {
+		return err
+	}
 
 	// Get all NamespaceLabels in the namespace
 	allInNamespace := &idandanielv1.NamespaceLabelList{}
@@ -76,30 +154,100 @@ func (r *NamespaceLabelReconciler) removeLabelsFromAssociatedNamespace(ctx conte
 		return err
 	}
 
-	// Get all the NamespaceLabels Labels in Namespace except the one being deleted
+	// Templated and sourced labels are removed with their current values
+	allInNamespace, _, _, err = r.resolveLabels(ctx, namespace, allInNamespace)
+	if err != nil {
+		r.recordFailure(err, "Failed to read the label sources", namespaceLabel, namespace)
+		return err
+	}
+	resolved, _, _, err := r.resolveLabels(ctx, namespace, &idandanielv1.NamespaceLabelList{Items: []idandanielv1.NamespaceLabel{*namespaceLabel}})
+	if err != nil {
+		r.recordFailure(err, "Failed to read the label sources", namespaceLabel, namespace)
+		return err
+	}
+	labelsToRemove := resolved.Items[0].Spec.Labels
+
+	// ApplyIfAbsent labels are removed with the value they were first set with
+	for key := range labelsToRemove {
+		if value, exists := namespace.Labels[key]; exists && namespaceLabel.ApplyPolicy(key) == idandanielv1.ApplyIfAbsent {
+			labelsToRemove[key] = value
+		}
+	}
+
+	// Get all the NamespaceLabels Labels and Annotations in Namespace except the one being deleted
 	labelToIgnore := allInNamespace.GetLabelsExcept(namespaceLabel)
+	annotationsToIgnore := allInNamespace.GetAnnotationsExcept(namespaceLabel)
 
-	// Get the namespace to remove labels from
-	namespace := &corev1.Namespace{}
-	if err := r.Get(ctx, types.NamespacedName{Name: namespaceLabel.GetNamespace()}, namespace); err != nil {
+	// Keep the labels of the ClusterNamespaceLabels as well
+	clusterLabels, _, err := r.clusterLabels(ctx, namespace)
+	if err != nil {
+		r.recordFailure(err, "Failed to list ClusterNamespaceLabels", namespaceLabel, namespace)
 		return err
 	}
+	maps.Copy(labelToIgnore, clusterLabels)
+
+	// A paused NamespaceLabel or Namespace is released without removing its labels and annotations, they are given up
+	// so neither the sync of the other NamespaceLabels nor the garbage collection removes them
+	if idandanielv1.IsPaused(namespaceLabel) || idandanielv1.IsPaused(namespace) {
+		logger.Info("Reconciliation is paused, leaving the labels on the Namespace")
+		if !r.DryRun {
+			wrappedNamespace := r.wrapNamespace(namespace.DeepCopy())
+			wrappedNamespace.LeaveLabels(keysExcept(labelsToRemove, labelToIgnore))
+			wrappedNamespace.LeaveAnnotations(keysExcept(namespaceLabel.Spec.Annotations, annotationsToIgnore))
+			if _, err := r.applyNamespace(ctx, namespace, wrappedNamespace); err != nil {
+				logger.Error(err, "Failed to give up NamespaceLabel's Labels on Namespace", LabelsField, namespaceLabel.Spec.Labels)
+				r.recordFailure(err, "Failed to give up the labels and annotations of the Namespace", namespaceLabel, namespace)
+				return err
+			}
+		}
+		r.Recorder.Eventf(namespaceLabel, corev1.EventTypeNormal, idandanielv1.ReasonPaused, "Reconciliation is paused, the labels and annotations were left on Namespace %s", namespace.GetName())
+		return nil
+	}
 
 	// Update the Namespace
-	wrappedNamespace := &wrappers.NamespaceWrapper{Namespace: namespace}
+	wrappedNamespace := r.wrapNamespace(namespace.DeepCopy())
+	previousLabels := ownedValues(namespace.Labels, wrappedNamespace.OwnedLabels())
+	previousAnnotations := ownedValues(namespace.Annotations, wrappedNamespace.OwnedAnnotations())
 	wrappedNamespace.RemoveLabelsExcept(labelsToRemove, labelToIgnore)
-	if err := r.Update(ctx, wrappedNamespace.Namespace); err != nil {
-		log.WithFields(logrus.Fields{
-			NamespaceLabelField: namespaceLabel.GetName(),
-			NamespaceField:      namespaceLabel.GetNamespace(),
-			LabelsField:         namespaceLabel.Spec.Labels,
-		}).Error("Failed to remove NamespaceLabel's Labels from Namespace")
+	wrappedNamespace.RemoveAnnotationsExcept(namespaceLabel.Spec.Annotations, annotationsToIgnore)
+	changedLabels := labelChanges(previousLabels, ownedValues(wrappedNamespace.Labels, wrappedNamespace.OwnedLabels())).withRestored(wrappedNamespace.RestoredLabels())
+	changedAnnotations := annotationChanges(previousAnnotations, ownedValues(wrappedNamespace.Annotations, wrappedNamespace.OwnedAnnotations()))
+
+	// In dry-run, and for an audited NamespaceLabel, only report what the deletion would remove
+	if r.DryRun || namespaceLabel.IsAudited() {
+		for _, changes := range []*metadataChanges{changedLabels, changedAnnotations} {
+			r.recordAudit(namespaceLabel, namespace.GetName(), changes)
+			r.recordAudit(namespace, namespace.GetName(), changes)
+		}
+		return nil
+	}
+
+	if _, err := r.applyNamespace(ctx, namespace, wrappedNamespace); err != nil {
+		logger.Error(err, "Failed to remove NamespaceLabel's Labels from Namespace", LabelsField, namespaceLabel.Spec.Labels)
+		r.recordFailure(err, "Failed to remove the labels and annotations from the Namespace", namespaceLabel, namespace)
 		return err
 	}
 
+	for _, changes := range []*metadataChanges{changedLabels, changedAnnotations} {
+		r.recordChanges(namespaceLabel, namespace.GetName(), changes)
+		r.recordChanges(namespace, namespace.GetName(), changes)
+	}
+	observeLabelChanges(namespace.GetName(), changedLabels, len(wrappedNamespace.OwnedLabels()))
+
 	return nil
 }
 
+// Get the keys of the values which are not in the values to keep
+func keysExcept(values map[string]string, valuesToKeep map[string]string) []string {
+	var keys []string
+	for key := range values {
+		if _, isKept := valuesToKeep[key]; !isKept {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
 // Generic function for either add or remove NamespaceLabel finalizer
 func (r *NamespaceLabelReconciler) changeFinalizer(ctx context.Context, namespaceLabel *idandanielv1.NamespaceLabel, finalizer string, method string) error {
 	changeMethods := map[string]interface{}{
@@ -120,12 +268,14 @@ func (r *NamespaceLabelReconciler) changeFinalizer(ctx context.Context, namespac
 // Add finalizer to NamespaceLabel if ir doesn't have one
 func (r *NamespaceLabelReconciler) addFinalizer(ctx context.Context, namespaceLabel *idandanielv1.NamespaceLabel, finalizer string) error {
 	if !controllerutil.ContainsFinalizer(namespaceLabel, finalizer) {
-		log.WithField(NamespaceLabelField, namespaceLabel.GetName()).Info("Adding finalizer to NamespaceLabel")
+		logger := ctrl.LoggerFrom(ctx)
+		logger.Info("Adding finalizer to NamespaceLabel")
 		if err := r.changeFinalizer(ctx, namespaceLabel, finalizer, AddFinalizer); err != nil {
-			log.WithError(err).WithField(NamespaceLabelField, namespaceLabel.GetName()).Error("Failed to add finalizer to NamespaceLabel")
+			logger.Error(err, "Failed to add finalizer to NamespaceLabel")
+			r.recordFailure(err, "Failed to add finalizer", namespaceLabel)
 			return err
 		}
-		log.WithField(NamespaceLabelField, namespaceLabel.GetName()).Info("Adding finalizer to NamespaceLabel successfully")
+		logger.Info("Adding finalizer to NamespaceLabel successfully")
 	}
 
 	return nil
@@ -136,7 +286,8 @@ func (r *NamespaceLabelReconciler) handleDeletion(ctx context.Context, namespace
 
 	if controllerutil.ContainsFinalizer(namespaceLabel, finalizer) {
 
-		log.WithField(NamespaceLabelField, namespaceLabel.GetName()).Info("Handling NamespaceLabel deletion")
+		logger := ctrl.LoggerFrom(ctx)
+		logger.Info("Handling NamespaceLabel deletion")
 
 		if err := r.removeLabelsFromAssociatedNamespace(ctx, namespaceLabel); err != nil {
 			return err
@@ -146,78 +297,1066 @@ func (r *NamespaceLabelReconciler) handleDeletion(ctx context.Context, namespace
 			return err
 		}
 
-		log.WithField(NamespaceLabelField, namespaceLabel.GetName()).Info("Deleted NamespaceLabel successfully")
+		logger.Info("Deleted NamespaceLabel successfully")
 	}
 
 	return nil
 }
 
 // Main function of syncing Between NamespaceLabels to the actual associated Namespace labels
-func (r *NamespaceLabelReconciler) sync(ctx context.Context, namespace string) error {
-	log.WithField(NamespaceField, namespace).Info("Syncing NamespaceLabels with Namespace")
+func (r *NamespaceLabelReconciler) sync(ctx context.Context, namespace string) (err error) {
+	start := time.Now()
+	defer func() { observeSync(start, err) }()
+	logger := ctrl.LoggerFrom(ctx)
+	logger.Info("Syncing NamespaceLabels with Namespace")
 
-	// Get all the NamespaceLabels of the current request Namespace and retrieve their labels
+	// Get all the NamespaceLabels of the current request Namespace and retrieve their labels, the ones being deleted
+	// remove their labels themselves
 	namespaceLabelList := &idandanielv1.NamespaceLabelList{}
 	if err := r.List(ctx, namespaceLabelList, &client.ListOptions{Namespace: namespace}); err != nil {
-		log.WithError(err).WithField(NamespaceField, namespace).Error("Failed to list NamespaceLabels in Namespace")
+		logger.Error(err, "Failed to list NamespaceLabels in Namespace")
 		return client.IgnoreNotFound(err)
 	}
-	labelsToAdd := namespaceLabelList.GetLabels()
+	namespaceLabelList = withoutDeleted(namespaceLabelList)
 
 	// Get the Namespace
 	n := &corev1.Namespace{}
 	if err := r.Get(ctx, types.NamespacedName{Name: namespace}, n); err != nil {
-		log.WithError(err).WithField(NamespaceField, namespace).Error("Failed to get namespace")
+		logger.Error(err, "Failed to get namespace")
+		if client.IgnoreNotFound(err) != nil {
+			r.recordFailure(err, "Failed to get the Namespace", namespaceLabelObjects(namespaceLabelList)...)
+		}
 		return client.IgnoreNotFound(err)
 	}
 
-	// Update the Namespace labels safely (keeps the kubernetes managment tags)
-	wrappedNamespace := &wrappers.NamespaceWrapper{Namespace: n}
-	wrappedNamespace.UpdateLabels(true, labelsToAdd)
-	if err := r.Update(ctx, wrappedNamespace.Namespace); err != nil {
-		log.WithFields(logrus.Fields{
-			LabelsField:    labelsToAdd,
-			NamespaceField: namespace,
-		}).Error("Failed to update namespace labels")
-		return client.IgnoreNotFound(err)
+	// A paused Namespace is left as it is, its NamespaceLabels only report the pause
+	if idandanielv1.IsPaused(n) {
+		logger.Info("Namespace is paused, skipping sync")
+		return r.updateStatuses(ctx, n, namespaceLabelList, &syncResult{namespacePaused: true})
+	}
+
+	// Render the label templates with the Namespace and read the label sources, the invalid labels are skipped
+	namespaceLabelList, invalid, unavailableSources, err := r.resolveLabels(ctx, n, namespaceLabelList)
+	if err != nil {
+		logger.Error(err, "Failed to read the label sources")
+		r.recordFailure(err, "Failed to read the label sources", namespaceLabelObjects(namespaceLabelList)...)
+		return err
+	}
+
+	// Paused NamespaceLabels keep the values currently on the Namespace
+	namespaceLabelList = r.freezePaused(n, namespaceLabelList)
+
+	// Audited NamespaceLabels keep the values they own frozen in the applied labels, the audit computes the labels with
+	// their actual labels. In dry-run the Namespace is audited even without NamespaceLabels, for the labels of the
+	// ClusterNamespaceLabels and the keys it would give up.
+	enforced, audited := r.splitByMode(n, namespaceLabelList)
+	var auditedNamespace *wrappers.NamespaceWrapper
+	var auditResult *syncResult
+	if len(audited.Items) > 0 || r.DryRun {
+		auditedNamespace, auditResult, err = r.desiredNamespace(ctx, n, namespaceLabelList, invalid, unavailableSources)
+		if err != nil {
+			return err
+		}
+	}
+
+	current := n
+	if !r.DryRun {
+		applied, err := r.enforce(ctx, n, enforced, invalid, unavailableSources)
+		if err != nil {
+			return err
+		}
+		current = applied
+	}
+
+	if auditResult == nil {
+		return nil
+	}
+	return r.audit(ctx, current, auditedNamespace, audited, auditResult)
+}
+
+// Get the NamespaceLabels of the list which are not being deleted
+func withoutDeleted(namespaceLabels *idandanielv1.NamespaceLabelList) *idandanielv1.NamespaceLabelList {
+	live := &idandanielv1.NamespaceLabelList{}
+	for _, namespaceLabel := range namespaceLabels.Items {
+		if !namespaceLabel.IsBeingDeleted() {
+			live.Items = append(live.Items, namespaceLabel)
+		}
+	}
+	return live
+}
+
+// Replace the labels and annotations of the paused NamespaceLabels with the values they own on the Namespace,
+// so the sync neither changes nor removes them
+func (r *NamespaceLabelReconciler) freezePaused(n *corev1.Namespace, namespaceLabels *idandanielv1.NamespaceLabelList) *idandanielv1.NamespaceLabelList {
+	return r.freeze(n, namespaceLabels, func(namespaceLabel *idandanielv1.NamespaceLabel) bool {
+		return idandanielv1.IsPaused(namespaceLabel)
+	})
+}
+
+// Replace the labels and annotations of the matching NamespaceLabels with the values they own on the Namespace
+func (r *NamespaceLabelReconciler) freeze(n *corev1.Namespace, namespaceLabels *idandanielv1.NamespaceLabelList, isFrozen func(*idandanielv1.NamespaceLabel) bool) *idandanielv1.NamespaceLabelList {
+	wrappedNamespace := r.wrapNamespace(n)
+	frozen := namespaceLabels.DeepCopy()
+	for i := range frozen.Items {
+		namespaceLabel := &frozen.Items[i]
+		if !isFrozen(namespaceLabel) {
+			continue
+		}
+		namespaceLabel.Spec.Labels = currentValues(namespaceLabel.Spec.Labels, n.Labels, wrappedNamespace.IsOwned)
+		namespaceLabel.Spec.Annotations = currentValues(namespaceLabel.Spec.Annotations, n.Annotations, wrappedNamespace.IsAnnotationOwned)
+		namespaceLabel.Spec.RemoveLabels = nil
+	}
+	return frozen
+}
+
+// Get the current values of the wanted keys which are owned
+func currentValues(wanted map[string]string, current map[string]string, isOwned func(string) bool) map[string]string {
+	values := make(map[string]string)
+	for key := range wanted {
+		if value, exists := current[key]; exists && isOwned(key) {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// Split the NamespaceLabels into the enforced and the audited ones, every NamespaceLabel is audited in dry-run.
+// The enforced list keeps the audited NamespaceLabels frozen like the paused ones, so switching to Audit does not
+// release their labels.
+func (r *NamespaceLabelReconciler) splitByMode(n *corev1.Namespace, namespaceLabels *idandanielv1.NamespaceLabelList) (*idandanielv1.NamespaceLabelList, *idandanielv1.NamespaceLabelList) {
+	audited := &idandanielv1.NamespaceLabelList{}
+	for _, namespaceLabel := range namespaceLabels.Items {
+		if r.DryRun || namespaceLabel.IsAudited() {
+			audited.Items = append(audited.Items, namespaceLabel)
+		}
+	}
+	if r.DryRun {
+		return &idandanielv1.NamespaceLabelList{}, audited
+	}
+	return r.freeze(n, namespaceLabels, (*idandanielv1.NamespaceLabel).IsAudited), audited
+}
+
+// Get the NamespaceLabels of the list which are enforced
+func withoutAudited(namespaceLabels *idandanielv1.NamespaceLabelList) *idandanielv1.NamespaceLabelList {
+	enforced := &idandanielv1.NamespaceLabelList{}
+	for _, namespaceLabel := range namespaceLabels.Items {
+		if !namespaceLabel.IsAudited() {
+			enforced.Items = append(enforced.Items, namespaceLabel)
+		}
+	}
+	return enforced
+}
+
+// Compute the labels and annotations the NamespaceLabels, the ClusterNamespaceLabels and the NamespaceLabelPolicies
+// want on the Namespace, returning an updated copy of it which is not written.
+// The labels of the NamespaceLabels are resolved, except the invalid labels which are left out.
+func (r *NamespaceLabelReconciler) desiredNamespace(ctx context.Context, n *corev1.Namespace, namespaceLabelList *idandanielv1.NamespaceLabelList, invalid invalidLabels, unavailableSources map[string][]unavailableSource) (*wrappers.NamespaceWrapper, *syncResult, error) {
+	logger := ctrl.LoggerFrom(ctx)
+	eventRecipients := append(namespaceLabelObjects(namespaceLabelList), n)
+	renderedNamespaceLabels := namespaceLabelList.WithoutLabels(invalid.keys())
+
+	// Labels denied by the NamespaceLabelPolicies never compete over a key
+	deniedLabels, err := r.deniedLabels(ctx, n, renderedNamespaceLabels)
+	if err != nil {
+		logger.Error(err, "Failed to list NamespaceLabelPolicies")
+		r.recordFailure(err, "Failed to list NamespaceLabelPolicies", eventRecipients...)
+		return nil, nil, err
+	}
+	allowedNamespaceLabels := renderedNamespaceLabels.WithoutLabels(deniedLabels)
+	labelsToAdd, conflicts := allowedNamespaceLabels.ResolveLabels()
+	annotationsToAdd, annotationConflicts := namespaceLabelList.ResolveAnnotations()
+
+	// The labels of the ClusterNamespaceLabels selecting the Namespace take precedence
+	clusterLabels, clusterOwners, err := r.clusterLabels(ctx, n)
+	if err != nil {
+		logger.Error(err, "Failed to list ClusterNamespaceLabels")
+		r.recordFailure(err, "Failed to list ClusterNamespaceLabels", eventRecipients...)
+		return nil, nil, err
+	}
+	allowedNamespaceLabels.OverrideLabels(labelsToAdd, conflicts, clusterLabels, clusterOwners, "ClusterNamespaceLabel")
+
+	// Update the Namespace labels and annotations safely (keeps the protected ones), honouring the apply policies
+	applyPolicies := allowedNamespaceLabels.ApplyPolicies(labelsToAdd, conflicts)
+	wrappedNamespace := r.wrapNamespace(n.DeepCopy())
+	wrappedNamespace.UpdateLabelsWithPolicies(labelsToAdd, applyPolicies)
+	wrappedNamespace.RemoveMatchingLabels(namespaceLabelList.RemovesLabel, labelsToAdd)
+	wrappedNamespace.UpdateAnnotations(true, annotationsToAdd)
+	return wrappedNamespace, &syncResult{
+		namespaceLabels:      wrappedNamespace.Labels,
+		conflicts:            conflicts,
+		applyPolicies:        applyPolicies,
+		removedLabels:        ownedValues(n.Labels, wrappedNamespace.RemovedLabels()),
+		protectedLabels:      r.protectedLabels(),
+		deniedLabels:         deniedLabels,
+		invalidLabels:        invalid,
+		unavailableSources:   unavailableSources,
+		namespaceAnnotations: wrappedNamespace.Annotations,
+		annotationConflicts:  annotationConflicts,
+		protectedAnnotations: r.protectedAnnotations(),
+	}, nil
+}
+
+// Apply the labels and annotations of the enforced NamespaceLabels to the Namespace, returning the updated Namespace.
+// The frozen audited NamespaceLabels keep their values but are reported by the audit.
+func (r *NamespaceLabelReconciler) enforce(ctx context.Context, n *corev1.Namespace, namespaceLabelList *idandanielv1.NamespaceLabelList, invalid invalidLabels, unavailableSources map[string][]unavailableSource) (*corev1.Namespace, error) {
+	logger := ctrl.LoggerFrom(ctx)
+	namespace := n.GetName()
+
+	wrappedNamespace, result, err := r.desiredNamespace(ctx, n, namespaceLabelList, invalid, unavailableSources)
+	if err != nil {
+		return nil, err
+	}
+	namespaceLabelList = withoutAudited(namespaceLabelList)
+	eventRecipients := append(namespaceLabelObjects(namespaceLabelList), n)
+	previousNamespace := r.wrapNamespace(n)
+	previousLabels := ownedValues(n.Labels, append(previousNamespace.OwnedLabels(), wrappedNamespace.RemovedLabels()...))
+	previousAnnotations := ownedValues(n.Annotations, previousNamespace.OwnedAnnotations())
+
+	applied, err := r.applyNamespace(ctx, n, wrappedNamespace)
+	if err != nil {
+		logger.Error(err, "Failed to update namespace labels", LabelsField, wrappedNamespace.Labels, AnnotationsField, wrappedNamespace.Annotations)
+		r.recordFailure(err, "Failed to apply the labels and annotations to the Namespace", eventRecipients...)
+		if isApplyConflict(err) {
+			observeSkipped(idandanielv1.ReasonOwnershipConflict)
+		}
+		result.err = err
+		if statusErr := r.updateStatuses(ctx, n, namespaceLabelList, result); statusErr != nil {
+			logger.Error(statusErr, "Failed to update NamespaceLabels status")
+		}
+		return nil, client.IgnoreNotFound(err)
+	}
+
+	// Report the changes on the Namespace, and on the NamespaceLabels which made them
+	changedLabels := labelChanges(previousLabels, ownedValues(applied.GetLabels(), wrappedNamespace.OwnedLabels())).withRestored(wrappedNamespace.RestoredLabels())
+	changedAnnotations := annotationChanges(previousAnnotations, ownedValues(applied.GetAnnotations(), wrappedNamespace.OwnedAnnotations()))
+	r.recordChanges(n, namespace, changedLabels)
+	r.recordChanges(n, namespace, changedAnnotations)
+	var appliedLabels, appliedAnnotations []map[string]string
+	for i := range namespaceLabelList.Items {
+		namespaceLabel := &namespaceLabelList.Items[i]
+		r.recordChanges(namespaceLabel, namespace, changedLabels.setBy(namespaceLabel.Spec.Labels, namespaceLabel.Status.AppliedLabels))
+		r.recordChanges(namespaceLabel, namespace, changedAnnotations.setBy(namespaceLabel.Spec.Annotations, namespaceLabel.Status.AppliedAnnotations))
+		appliedLabels = append(appliedLabels, namespaceLabel.Status.AppliedLabels)
+		appliedAnnotations = append(appliedAnnotations, namespaceLabel.Status.AppliedAnnotations)
+	}
+	observeDrift(appliedLabels, n.Labels, applied.GetLabels())
+	observeDrift(appliedAnnotations, n.Annotations, applied.GetAnnotations())
+	observeLabelChanges(namespace, changedLabels, len(wrappedNamespace.OwnedLabels()))
+
+	// Report the outcome of the sync in every NamespaceLabel of the Namespace
+	result.namespaceLabels = applied.GetLabels()
+	result.conflictedLabels = wrappedNamespace.ConflictedLabels()
+	result.conflictedAnnotations = wrappedNamespace.ConflictedAnnotations()
+	result.namespaceAnnotations = applied.GetAnnotations()
+	return applied, r.updateStatuses(ctx, n, namespaceLabelList, result)
+}
+
+// Report the changes the audited NamespaceLabels would make to the Namespace in their status and events, without applying them
+func (r *NamespaceLabelReconciler) audit(ctx context.Context, current *corev1.Namespace, auditedNamespace *wrappers.NamespaceWrapper, namespaceLabelList *idandanielv1.NamespaceLabelList, result *syncResult) error {
+	currentNamespace := r.wrapNamespace(current)
+	changedLabels := labelChanges(
+		ownedValues(current.Labels, append(currentNamespace.OwnedLabels(), auditedNamespace.RemovedLabels()...)),
+		ownedValues(auditedNamespace.Labels, auditedNamespace.OwnedLabels()),
+	)
+	changedAnnotations := annotationChanges(
+		ownedValues(current.Annotations, currentNamespace.OwnedAnnotations()),
+		ownedValues(auditedNamespace.Annotations, auditedNamespace.OwnedAnnotations()),
+	)
+	result.audit = true
+	result.pendingChanges = pendingChanges(changedLabels, changedAnnotations)
+
+	// Report the pending changes once, when they differ from the ones the NamespaceLabels already report. Without
+	// NamespaceLabels nothing records them, they are reported on the Namespace on every sync.
+	changed := len(namespaceLabelList.Items) == 0
+	for i := range namespaceLabelList.Items {
+		namespaceLabel := &namespaceLabelList.Items[i]
+		if namespaceLabel.IsBeingDeleted() || equality.Semantic.DeepEqual(namespaceLabel.Status.PendingChanges, result.pendingChanges) {
+			continue
+		}
+		changed = true
+		r.recordAudit(namespaceLabel, current.GetName(), changedLabels)
+		r.recordAudit(namespaceLabel, current.GetName(), changedAnnotations)
+	}
+	if changed {
+		r.recordAudit(current, current.GetName(), changedLabels)
+		r.recordAudit(current, current.GetName(), changedAnnotations)
+	}
+
+	return r.updateStatuses(ctx, current, namespaceLabelList, result)
+}
+
+// Get the labels of the ClusterNamespaceLabels selecting the Namespace, and the ClusterNamespaceLabel each label comes from
+func (r *NamespaceLabelReconciler) clusterLabels(ctx context.Context, namespace *corev1.Namespace) (map[string]string, map[string]string, error) {
+	clusterNamespaceLabelList := &idandanielv1.ClusterNamespaceLabelList{}
+	if err := r.List(ctx, clusterNamespaceLabelList); err != nil {
+		return nil, nil, err
+	}
+
+	clusterLabels, clusterOwners := clusterNamespaceLabelList.GetLabelsFor(namespace)
+	return clusterLabels, clusterOwners, nil
+}
+
+// Get the labels of the NamespaceLabels denied by the NamespaceLabelPolicies selecting the Namespace
+func (r *NamespaceLabelReconciler) deniedLabels(ctx context.Context, namespace *corev1.Namespace, namespaceLabels *idandanielv1.NamespaceLabelList) (map[string]map[string]string, error) {
+	policyList := &idandanielv1.NamespaceLabelPolicyList{}
+	if err := r.List(ctx, policyList); err != nil {
+		return nil, err
+	}
+
+	return policyList.DeniedLabels(namespace, namespaceLabels), nil
+}
+
+// Server-side apply the labels and annotations owned by NamespaceLabels, returning the updated Namespace.
+// The removed labels set by others, the labels restored to their previous values and the keys given up are patched first:
+// the restored and given up values are then owned by the update instead of the apply, so the apply giving them up does
+// not remove them, and the previous values are only dropped from the Namespace once they are restored.
+// Keys managed by others with a different value fail with a conflict unless ForceOwnership is set. The keys the
+// NamespaceLabels already owned were changed by others and are set back by forcing their ownership, the keys they never
+// owned are given up and reported, and the rest of the keys are applied again.
+// Nothing is written when the current Namespace is already up to date.
+func (r *NamespaceLabelReconciler) applyNamespace(ctx context.Context, current *corev1.Namespace, wrappedNamespace *wrappers.NamespaceWrapper) (*corev1.Namespace, error) {
+	if !wrappedNamespace.HasChanges(current, FieldManager) {
+		ctrl.LoggerFrom(ctx).V(1).Info("Namespace is up to date, skipping the write")
+		return current, nil
+	}
+
+	labels := make(map[string]string)
+	maps.Copy(labels, wrappedNamespace.RestoredLabels())
+	maps.Copy(labels, wrappedNamespace.LeftLabels())
+	if removed, annotations := wrappedNamespace.RemovedLabels(), wrappedNamespace.LeftAnnotations(); len(removed) > 0 || len(labels) > 0 || len(annotations) > 0 {
+		patch, err := metadataPatch(removed, labels, annotations)
+		if err != nil {
+			return nil, err
+		}
+		patched := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: current.Name}}
+		if err := r.Patch(ctx, patched, patch, client.FieldOwner(FieldManager)); err != nil {
+			return nil, err
+		}
+	}
+
+	applyConfiguration := wrappedNamespace.ApplyConfiguration()
+
+	patchOptions := []client.PatchOption{client.FieldOwner(FieldManager)}
+	if r.ForceOwnership {
+		patchOptions = append(patchOptions, client.ForceOwnership)
+	}
+
+	err := r.Patch(ctx, applyConfiguration, client.Apply, patchOptions...)
+	if labels, annotations := conflictingKeys(err); len(labels) > 0 || len(annotations) > 0 {
+		currentNamespace := r.wrapNamespace(current)
+		foreignLabels := withoutOwned(labels, currentNamespace.IsOwned)
+		foreignAnnotations := withoutOwned(annotations, currentNamespace.IsAnnotationOwned)
+		if len(foreignLabels) > 0 || len(foreignAnnotations) > 0 {
+			ctrl.LoggerFrom(ctx).Info("Keys are managed by others with different values, applying without them", LabelsField, foreignLabels, AnnotationsField, foreignAnnotations)
+			wrappedNamespace.SkipConflicts(current, foreignLabels, foreignAnnotations)
+			applyConfiguration = wrappedNamespace.ApplyConfiguration()
+		}
+		if len(foreignLabels) < len(labels) || len(foreignAnnotations) < len(annotations) {
+			ctrl.LoggerFrom(ctx).Info("Owned keys were changed by others, setting them back", LabelsField, labels, AnnotationsField, annotations)
+			patchOptions = append(patchOptions, client.ForceOwnership)
+		}
+		err = r.Patch(ctx, applyConfiguration, client.Apply, patchOptions...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return applyConfiguration, nil
+}
+
+// Get the fields managed by other field managers a server-side apply conflict is caused by, nil for other errors
+func applyConflictCauses(err error) []metav1.StatusCause {
+	var status apierrors.APIStatus
+	if !apierrors.IsConflict(err) || !errors.As(err, &status) || status.Status().Details == nil {
+		return nil
+	}
+
+	var causes []metav1.StatusCause
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type == metav1.CauseTypeFieldManagerConflict {
+			causes = append(causes, cause)
+		}
+	}
+	return causes
+}
+
+// Returns true when the error is a server-side apply conflict with other field managers, other conflicts such as
+// stale resource versions are plain failures
+func isApplyConflict(err error) bool {
+	return len(applyConflictCauses(err)) > 0
+}
+
+// Get the label and annotation keys a server-side apply conflict is caused by
+func conflictingKeys(err error) ([]string, []string) {
+	var labels, annotations []string
+	for _, cause := range applyConflictCauses(err) {
+		switch {
+		case strings.HasPrefix(cause.Field, ".metadata.labels."):
+			labels = append(labels, strings.TrimPrefix(cause.Field, ".metadata.labels."))
+		case strings.HasPrefix(cause.Field, ".metadata.annotations."):
+			annotations = append(annotations, strings.TrimPrefix(cause.Field, ".metadata.annotations."))
+		}
+	}
+	return labels, annotations
+}
+
+// Get the keys which are not owned
+func withoutOwned(keys []string, isOwned func(key string) bool) []string {
+	var foreign []string
+	for _, key := range keys {
+		if !isOwned(key) {
+			foreign = append(foreign, key)
+		}
+	}
+	return foreign
+}
+
+// Get a merge patch removing the labels from the Namespace and setting the labels and annotations
+func metadataPatch(removed []string, labels map[string]string, annotations map[string]string) (client.Patch, error) {
+	patchedLabels := make(map[string]interface{}, len(removed)+len(labels))
+	for _, key := range removed {
+		patchedLabels[key] = nil
+	}
+	for key, value := range labels {
+		patchedLabels[key] = value
+	}
+	metadata := map[string]interface{}{"labels": patchedLabels}
+	if len(annotations) > 0 {
+		metadata["annotations"] = annotations
+	}
+	data, err := json.Marshal(map[string]interface{}{"metadata": metadata})
+	if err != nil {
+		return nil, err
+	}
+	return client.RawPatch(types.MergePatchType, data), nil
+}
+
+// Outcome of syncing the labels of a Namespace, used to compute the status of its NamespaceLabels
+type syncResult struct {
+	// Labels set on the Namespace after the sync
+	namespaceLabels map[string]string
+	// Conflicts every NamespaceLabel lost, keyed by its name
+	conflicts map[string][]idandanielv1.LabelConflict
+	// Apply policies the labels were applied with, keyed by the label key
+	applyPolicies map[string]idandanielv1.ApplyPolicy
+	// Labels set by others which the sync removed, with their previous values
+	removedLabels map[string]string
+	// Labels NamespaceLabels may not change
+	protectedLabels *protected.Rules
+	// Messages of the labels denied by NamespaceLabelPolicies, keyed by the NamespaceLabel name and the label key
+	deniedLabels map[string]map[string]string
+	// Labels which can never be applied, keyed by the NamespaceLabel name and the label key
+	invalidLabels invalidLabels
+	// Label sources which are missing or not shared with the Namespace, keyed by the NamespaceLabel name
+	unavailableSources map[string][]unavailableSource
+	// Annotations set on the Namespace after the sync
+	namespaceAnnotations map[string]string
+	// Annotation conflicts every NamespaceLabel lost, keyed by its name
+	annotationConflicts map[string][]idandanielv1.LabelConflict
+	// Annotations NamespaceLabels may not change
+	protectedAnnotations *protected.Rules
+	// Keys of the labels managed by others with a different value, which were not applied
+	conflictedLabels []string
+	// Keys of the annotations managed by others with a different value, which were not applied
+	conflictedAnnotations []string
+	// Whether the labels were computed in audit mode instead of being applied
+	audit bool
+	// Changes computed in audit mode
+	pendingChanges *idandanielv1.PendingChanges
+	// Whether the Namespace is paused and was not synced
+	namespacePaused bool
+	// Error the sync failed with
+	err error
+}
+
+// Update the status of every NamespaceLabel in the list according to the Namespace labels
+func (r *NamespaceLabelReconciler) updateStatuses(ctx context.Context, namespace *corev1.Namespace, namespaceLabels *idandanielv1.NamespaceLabelList, result *syncResult) error {
+	for i := range namespaceLabels.Items {
+		namespaceLabel := &namespaceLabels.Items[i]
+		if namespaceLabel.IsBeingDeleted() {
+			continue
+		}
+
+		original := namespaceLabel.DeepCopy()
+		setStatus(namespaceLabel, result)
+		if equality.Semantic.DeepEqual(original.Status, namespaceLabel.Status) {
+			continue
+		}
+
+		if err := r.Status().Patch(ctx, namespaceLabel, client.MergeFrom(original)); client.IgnoreNotFound(err) != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "Failed to update NamespaceLabel status", NamespaceLabelField, namespaceLabel.GetName())
+			r.recordFailure(err, "Failed to update the status", namespaceLabel)
+			return err
+		}
+		r.recordSkipped(namespace, namespaceLabel, "Label", namespaceLabel.Status.SkippedLabels, original.Status.SkippedLabels)
+		r.recordSkipped(namespace, namespaceLabel, "Annotation", namespaceLabel.Status.SkippedAnnotations, original.Status.SkippedAnnotations)
 	}
 
 	return nil
 }
 
-// Main reconcile loop
-func (r *NamespaceLabelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	// Get the associated Namespace
-	namespaceLabel := &idandanielv1.NamespaceLabel{}
-	if err := r.Get(ctx, req.NamespacedName, namespaceLabel, &client.GetOptions{}); err != nil {
-		if client.IgnoreNotFound(err) != nil {
-			log.WithError(err).WithField(NamespaceLabelField, req.NamespacedName).Error("Failed to get NamespaceLabel")
+// Update the status of a paused NamespaceLabel which is not synced
+func (r *NamespaceLabelReconciler) updatePausedStatus(ctx context.Context, namespaceLabel *idandanielv1.NamespaceLabel) error {
+	original := namespaceLabel.DeepCopy()
+	setStatus(namespaceLabel, &syncResult{})
+	if equality.Semantic.DeepEqual(original.Status, namespaceLabel.Status) {
+		return nil
+	}
+
+	if err := r.Status().Patch(ctx, namespaceLabel, client.MergeFrom(original)); client.IgnoreNotFound(err) != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to update NamespaceLabel status")
+		r.recordFailure(err, "Failed to update the status", namespaceLabel)
+		return err
+	}
+	return nil
+}
+
+// Compute the status of a NamespaceLabel from the labels currently set on its Namespace, or the ones it would set in audit mode.
+// The status of a paused NamespaceLabel only reports the pause.
+func setStatus(namespaceLabel *idandanielv1.NamespaceLabel, result *syncResult) {
+	if message := pausedMessage(namespaceLabel, result.namespacePaused); message != "" {
+		meta.SetStatusCondition(&namespaceLabel.Status.Conditions, metav1.Condition{
+			Type:               idandanielv1.ConditionPaused,
+			Status:             metav1.ConditionTrue,
+			Reason:             idandanielv1.ReasonPaused,
+			Message:            message,
+			ObservedGeneration: namespaceLabel.GetGeneration(),
+		})
+		return
+	}
+	meta.SetStatusCondition(&namespaceLabel.Status.Conditions, metav1.Condition{
+		Type:               idandanielv1.ConditionPaused,
+		Status:             metav1.ConditionFalse,
+		Reason:             idandanielv1.ReasonReconciling,
+		Message:            "Reconciliation is not paused",
+		ObservedGeneration: namespaceLabel.GetGeneration(),
+	})
+
+	setSyncStatus(namespaceLabel, result)
+	if result.err != nil {
+		return
+	}
+
+	status := &namespaceLabel.Status
+	if unavailable := result.unavailableSources[namespaceLabel.GetName()]; len(unavailable) > 0 {
+		// The conditions report the reason of the first unavailable source and the messages of all of them
+		messages := make([]string, 0, len(unavailable))
+		for _, source := range unavailable {
+			messages = append(messages, source.message)
 		}
-		return ctrl.Result{}, client.IgnoreNotFound(err)
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               idandanielv1.ConditionSynced,
+			Status:             metav1.ConditionFalse,
+			Reason:             unavailable[0].reason,
+			Message:            strings.Join(messages, "; "),
+			ObservedGeneration: namespaceLabel.GetGeneration(),
+		})
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               idandanielv1.ConditionReady,
+			Status:             metav1.ConditionFalse,
+			Reason:             unavailable[0].reason,
+			Message:            "Labels of missing or unshared sources are not applied to the Namespace",
+			ObservedGeneration: namespaceLabel.GetGeneration(),
+		})
 	}
 
-	// Handle finalizer
-	if !namespaceLabel.IsBeingDeleted() {
-		if err := r.addFinalizer(ctx, namespaceLabel, finalizer); err != nil {
-			return ctrl.Result{}, err
+	status.PendingChanges = result.pendingChanges
+	if !result.audit {
+		return
+	}
+	pending := 0
+	if status.PendingChanges != nil {
+		pending = len(status.PendingChanges.AddedLabels) + len(status.PendingChanges.RemovedLabels) +
+			len(status.PendingChanges.AddedAnnotations) + len(status.PendingChanges.RemovedAnnotations)
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               idandanielv1.ConditionReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             idandanielv1.ReasonAudit,
+		Message:            fmt.Sprintf("Audit mode, %d changes to the Namespace are pending and not applied", pending),
+		ObservedGeneration: namespaceLabel.GetGeneration(),
+	})
+}
+
+// Explain why the reconciliation of the NamespaceLabel is paused, empty when it is not
+func pausedMessage(namespaceLabel *idandanielv1.NamespaceLabel, namespacePaused bool) string {
+	switch {
+	case namespacePaused:
+		return fmt.Sprintf("Reconciliation is paused by the %s annotation of the Namespace", idandanielv1.PausedAnnotation)
+	case idandanielv1.IsPaused(namespaceLabel):
+		return fmt.Sprintf("Reconciliation is paused by the %s annotation", idandanielv1.PausedAnnotation)
+	}
+	return ""
+}
+
+func setSyncStatus(namespaceLabel *idandanielv1.NamespaceLabel, result *syncResult) {
+	status := &namespaceLabel.Status
+	status.ObservedGeneration = namespaceLabel.GetGeneration()
+
+	if result.err != nil {
+		reason := idandanielv1.ReasonSyncFailed
+		if isApplyConflict(result.err) {
+			reason = idandanielv1.ReasonOwnershipConflict
+			meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+				Type:               idandanielv1.ConditionConflicted,
+				Status:             metav1.ConditionTrue,
+				Reason:             reason,
+				Message:            "Labels are managed by others with different values",
+				ObservedGeneration: namespaceLabel.GetGeneration(),
+			})
+		}
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               idandanielv1.ConditionSynced,
+			Status:             metav1.ConditionFalse,
+			Reason:             reason,
+			Message:            result.err.Error(),
+			ObservedGeneration: namespaceLabel.GetGeneration(),
+		})
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               idandanielv1.ConditionReady,
+			Status:             metav1.ConditionFalse,
+			Reason:             idandanielv1.ReasonSyncFailed,
+			Message:            "Failed to sync the labels with the Namespace",
+			ObservedGeneration: namespaceLabel.GetGeneration(),
+		})
+		return
+	}
+
+	rules := &wrappers.NamespaceWrapper{ProtectedLabels: result.protectedLabels, ProtectedAnnotations: result.protectedAnnotations}
+	status.AppliedLabels, status.SkippedLabels = compareKeys(
+		"label",
+		namespaceLabel.Spec.Labels,
+		namespaceLabel.Spec.ApplyPolicies,
+		result.namespaceLabels,
+		result.conflicts[namespaceLabel.GetName()],
+		result.deniedLabels[namespaceLabel.GetName()],
+		withOwnershipConflicts("label", namespaceLabel.Spec.Labels, result.invalidLabels[namespaceLabel.GetName()], result.conflicts[namespaceLabel.GetName()], result.conflictedLabels),
+		rules.IsProtected,
+	)
+	status.AppliedAnnotations, status.SkippedAnnotations = compareKeys(
+		"annotation",
+		namespaceLabel.Spec.Annotations,
+		nil,
+		result.namespaceAnnotations,
+		result.annotationConflicts[namespaceLabel.GetName()],
+		nil,
+		withOwnershipConflicts("annotation", namespaceLabel.Spec.Annotations, nil, result.annotationConflicts[namespaceLabel.GetName()], result.conflictedAnnotations),
+		rules.IsAnnotationProtected,
+	)
+	status.ApplyPolicies = appliedPolicies(namespaceLabel, result.applyPolicies)
+	now := metav1.Now()
+	if !result.audit {
+		status.RemovedLabels = recordRemovals(namespaceLabel, result.removedLabels, now)
+	}
+	if status.LastSyncTime == nil || now.Sub(status.LastSyncTime.Time) >= lastSyncTimeGranularity {
+		status.LastSyncTime = &now
+	}
+
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               idandanielv1.ConditionSynced,
+		Status:             metav1.ConditionTrue,
+		Reason:             idandanielv1.ReasonSyncSucceeded,
+		Message:            "Labels were synced with the Namespace",
+		ObservedGeneration: namespaceLabel.GetGeneration(),
+	})
+
+	if len(status.SkippedLabels) == 0 && len(status.SkippedAnnotations) == 0 {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               idandanielv1.ConditionConflicted,
+			Status:             metav1.ConditionFalse,
+			Reason:             idandanielv1.ReasonNoConflicts,
+			Message:            "No label conflicts",
+			ObservedGeneration: namespaceLabel.GetGeneration(),
+		})
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               idandanielv1.ConditionReady,
+			Status:             metav1.ConditionTrue,
+			Reason:             idandanielv1.ReasonLabelsApplied,
+			Message:            "All labels and annotations are applied to the Namespace",
+			ObservedGeneration: namespaceLabel.GetGeneration(),
+		})
+		return
+	}
+
+	var messages []string
+	if len(status.SkippedLabels) > 0 {
+		messages = append(messages, fmt.Sprintf("Labels were skipped: %s", strings.Join(skippedKeys(status.SkippedLabels), ", ")))
+	}
+	if len(status.SkippedAnnotations) > 0 {
+		messages = append(messages, fmt.Sprintf("Annotations were skipped: %s", strings.Join(skippedKeys(status.SkippedAnnotations), ", ")))
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               idandanielv1.ConditionConflicted,
+		Status:             metav1.ConditionTrue,
+		Reason:             idandanielv1.ReasonLabelConflict,
+		Message:            strings.Join(messages, "; "),
+		ObservedGeneration: namespaceLabel.GetGeneration(),
+	})
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:   idandanielv1.ConditionReady,
+		Status: metav1.ConditionFalse,
+		Reason: idandanielv1.ReasonLabelsSkipped,
+		Message: fmt.Sprintf("%d of %d labels and %d of %d annotations are applied to the Namespace",
+			len(status.AppliedLabels), len(namespaceLabel.Spec.Labels),
+			len(status.AppliedAnnotations), len(namespaceLabel.Spec.Annotations)),
+		ObservedGeneration: namespaceLabel.GetGeneration(),
+	})
+}
+
+// Split the wanted keys of the given kind into the ones applied to the Namespace and the skipped ones.
+// ApplyIfAbsent keys are applied with any value, and RemoveOnly keys are only skipped while still set on the Namespace.
+func compareKeys(kind string, wanted map[string]string, policies map[string]idandanielv1.ApplyPolicy, current map[string]string, conflicts []idandanielv1.LabelConflict, denied map[string]string, invalid map[string]idandanielv1.SkippedLabel, isProtected func(string) bool) (map[string]string, []idandanielv1.SkippedLabel) {
+	var applied map[string]string
+	var skipped []idandanielv1.SkippedLabel
+	for _, key := range sortedKeys(wanted) {
+		value := wanted[key]
+		_, isDenied := denied[key]
+		_, isInvalid := invalid[key]
+		isAllowed := !isProtected(key) && !isDenied && !isInvalid
+		currentValue, exists := current[key]
+		switch policies[key] {
+		case idandanielv1.ApplyIfAbsent:
+			if exists && isAllowed && !hasConflict(conflicts, key) {
+				value = currentValue
+			}
+		case idandanielv1.RemoveOnly:
+			if !exists {
+				continue
+			}
+			removal := skippedKey(kind, key, value, conflicts, denied, invalid, current, isProtected)
+			if removal.Reason == idandanielv1.ReasonLabelConflict {
+				removal.Message = fmt.Sprintf("The %s is still set on the Namespace", kind)
+			}
+			skipped = append(skipped, removal)
+			continue
+		}
+		if exists && currentValue == value && isAllowed {
+			if applied == nil {
+				applied = make(map[string]string)
+			}
+			applied[key] = value
+			continue
+		}
+		skipped = append(skipped, skippedKey(kind, key, value, conflicts, denied, invalid, current, isProtected))
+	}
+	return applied, skipped
+}
+
+// Record the labels the RemoveLabels of the NamespaceLabel removed in the sync, keeping the earlier removals
+// which still match them
+func recordRemovals(namespaceLabel *idandanielv1.NamespaceLabel, removed map[string]string, now metav1.Time) []idandanielv1.RemovedLabel {
+	var records []idandanielv1.RemovedLabel
+	for _, record := range namespaceLabel.Status.RemovedLabels {
+		if _, isRemovedAgain := removed[record.Key]; !isRemovedAgain && namespaceLabel.RemovesLabel(record.Key) {
+			records = append(records, record)
+		}
+	}
+	for key, value := range removed {
+		if namespaceLabel.RemovesLabel(key) {
+			records = append(records, idandanielv1.RemovedLabel{Key: key, Value: value, RemovedAt: now})
+		}
+	}
+	slices.SortFunc(records, func(a, b idandanielv1.RemovedLabel) bool {
+		return a.Key < b.Key
+	})
+	return records
+}
+
+// Add the wanted keys managed by others with a different value to the invalid keys of a NamespaceLabel,
+// unless the NamespaceLabel lost them to others anyway
+func withOwnershipConflicts(kind string, wanted map[string]string, invalid map[string]idandanielv1.SkippedLabel, conflicts []idandanielv1.LabelConflict, conflicted []string) map[string]idandanielv1.SkippedLabel {
+	if len(conflicted) == 0 {
+		return invalid
+	}
+
+	skipped := maps.Clone(invalid)
+	if skipped == nil {
+		skipped = make(map[string]idandanielv1.SkippedLabel)
+	}
+	for _, key := range conflicted {
+		value, isWanted := wanted[key]
+		if _, isInvalid := skipped[key]; !isWanted || isInvalid || hasConflict(conflicts, key) {
+			continue
+		}
+		skipped[key] = idandanielv1.SkippedLabel{
+			Key:     key,
+			Value:   value,
+			Reason:  idandanielv1.ReasonOwnershipConflict,
+			Message: fmt.Sprintf("The %s is managed by others with a different value", kind),
+		}
+	}
+	return skipped
+}
+
+func hasConflict(conflicts []idandanielv1.LabelConflict, key string) bool {
+	return slices.IndexFunc(conflicts, func(conflict idandanielv1.LabelConflict) bool { return conflict.Key == key }) >= 0
+}
+
+// Get the apply policy every label of the NamespaceLabel was applied with, the policy of the winner for lost labels
+func appliedPolicies(namespaceLabel *idandanielv1.NamespaceLabel, policies map[string]idandanielv1.ApplyPolicy) map[string]idandanielv1.ApplyPolicy {
+	if len(namespaceLabel.Spec.Labels) == 0 {
+		return nil
+	}
+	applied := make(map[string]idandanielv1.ApplyPolicy, len(namespaceLabel.Spec.Labels))
+	for key := range namespaceLabel.Spec.Labels {
+		if policy, exists := policies[key]; exists && namespaceLabel.ApplyPolicy(key) != idandanielv1.RemoveOnly {
+			applied[key] = policy
+			continue
+		}
+		applied[key] = namespaceLabel.ApplyPolicy(key)
+	}
+	return applied
+}
+
+func skippedKeys(skipped []idandanielv1.SkippedLabel) []string {
+	keys := make([]string, 0, len(skipped))
+	for _, s := range skipped {
+		keys = append(keys, s.Key)
+	}
+	return keys
+}
+
+// Explain why a label or annotation of the NamespaceLabel is not set on the Namespace
+func skippedKey(kind string, key string, value string, conflicts []idandanielv1.LabelConflict, denied map[string]string, invalid map[string]idandanielv1.SkippedLabel, current map[string]string, isProtected func(string) bool) idandanielv1.SkippedLabel {
+	skipped := idandanielv1.SkippedLabel{
+		Key:     key,
+		Value:   value,
+		Reason:  idandanielv1.ReasonLabelConflict,
+		Message: fmt.Sprintf("The Namespace has a different value for the %s", kind),
+	}
+
+	if isProtected(key) {
+		skipped.Reason = idandanielv1.ReasonLabelProtected
+		skipped.Message = fmt.Sprintf("The %s is protected and can not be set by NamespaceLabels", kind)
+		return skipped
+	}
+
+	if invalidLabel, isInvalid := invalid[key]; isInvalid {
+		return invalidLabel
+	}
+
+	if message, isDenied := denied[key]; isDenied {
+		skipped.Reason = idandanielv1.ReasonPolicyDenied
+		skipped.Message = message
+		return skipped
+	}
+
+	for _, conflict := range conflicts {
+		if conflict.Key != key {
+			continue
+		}
+		switch {
+		case conflict.Refused:
+			skipped.Reason = idandanielv1.ReasonConflictRefused
+			skipped.Message = fmt.Sprintf("Other NamespaceLabels set the %s to a different value", kind)
+		case conflict.Winner == "":
+			skipped.Message = fmt.Sprintf("All the NamespaceLabels setting the %s refused the conflict", kind)
+		default:
+			skipped.Reason = idandanielv1.ReasonLabelOverridden
+			skipped.Message = fmt.Sprintf("Overridden by %s %s", conflict.WinnerKind, conflict.Winner)
 		}
-	} else {
-		if err := r.handleDeletion(ctx, namespaceLabel, finalizer); err != nil {
+		return skipped
+	}
+
+	if _, exists := current[key]; !exists {
+		skipped.Message = fmt.Sprintf("The %s is not set on the Namespace", kind)
+	}
+
+	return skipped
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := maps.Keys(m)
+	slices.Sort(keys)
+	return keys
+}
+
+// Main reconcile loop, keyed by the Namespace name so the events of all its NamespaceLabels collapse into one sync
+func (r *NamespaceLabelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// The controller logger already carries the Namespace and the reconcile ID
+	logger := ctrl.LoggerFrom(ctx)
+	namespace := req.Name
+
+	// Get the NamespaceLabels of the Namespace
+	namespaceLabelList := &idandanielv1.NamespaceLabelList{}
+	if err := r.List(ctx, namespaceLabelList, client.InNamespace(namespace)); err != nil {
+		logger.Error(err, "Failed to list NamespaceLabels in Namespace")
+		return ctrl.Result{}, err
+	}
+
+	// Handle finalizers, the NamespaceLabels being deleted remove their labels before the others are synced
+	var live []*idandanielv1.NamespaceLabel
+	for i := range namespaceLabelList.Items {
+		namespaceLabel := &namespaceLabelList.Items[i]
+		namespaceLabelCtx := ctrl.LoggerInto(ctx, logger.WithValues(NamespaceLabelField, namespaceLabel.GetName()))
+		if namespaceLabel.IsBeingDeleted() {
+			if err := r.handleDeletion(namespaceLabelCtx, namespaceLabel, finalizer); err != nil {
+				return ctrl.Result{}, err
+			}
+			continue
+		}
+		if err := r.addFinalizer(namespaceLabelCtx, namespaceLabel, finalizer); err != nil {
 			return ctrl.Result{}, err
 		}
+		live = append(live, namespaceLabel)
+	}
+
+	// Without unpaused NamespaceLabels only the ClusterNamespaceLabels are synced, the paused ones keep their current values
+	unpaused := slices.IndexFunc(live, func(namespaceLabel *idandanielv1.NamespaceLabel) bool { return !idandanielv1.IsPaused(namespaceLabel) })
+	if unpaused < 0 {
+		for _, namespaceLabel := range live {
+			logger.Info("NamespaceLabel is paused, skipping sync", NamespaceLabelField, namespaceLabel.GetName())
+			if err := r.updatePausedStatus(ctx, namespaceLabel); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return r.syncClusterLabels(ctx, namespace, len(live) == 0)
+	}
+
+	// Sync between NamespaceLabel CRs to Namespace labels
+	if err := r.sync(ctx, namespace); err != nil {
+		return ctrl.Result{}, err
+	}
+	// Sync again later to correct the drift of missed events, when the first NamespaceLabel is due
+	return ctrl.Result{RequeueAfter: r.nextResync(live)}, nil
+}
+
+// Sync a Namespace without unpaused NamespaceLabels while ClusterNamespaceLabels select it, synced again like the
+// NamespaceLabels. Without any NamespaceLabel, it is also synced to remove the keys it still owns, e.g. when the
+// ClusterNamespaceLabels setting them stopped selecting it.
+func (r *NamespaceLabelReconciler) syncClusterLabels(ctx context.Context, namespace string, releaseOwned bool) (ctrl.Result, error) {
+	logger := ctrl.LoggerFrom(ctx)
+
+	n := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: namespace}, n); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "Failed to get namespace")
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	clusterLabels, _, err := r.clusterLabels(ctx, n)
+	if err != nil {
+		logger.Error(err, "Failed to list ClusterNamespaceLabels")
+		return ctrl.Result{}, err
+	}
+
+	wrappedNamespace := r.wrapNamespace(n)
+	hasOwned := len(wrappedNamespace.OwnedLabels()) > 0 || len(wrappedNamespace.OwnedAnnotations()) > 0
+	if len(clusterLabels) == 0 && !(releaseOwned && hasOwned) {
 		return ctrl.Result{}, nil
 	}
 
-	// Sync between NamespaceLabel CR to Namespace labels
-	if err := r.sync(ctx, req.NamespacedName.Namespace); err != nil {
+	if err := r.sync(ctx, namespace); err != nil {
 		return ctrl.Result{}, err
 	}
-	return ctrl.Result{}, nil
+	if len(clusterLabels) == 0 || r.ResyncPeriod <= 0 {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: wait.Jitter(r.ResyncPeriod, resyncJitter)}, nil
+}
+
+// Queue a sync of the Namespace, reconciled with the other events of the Namespace
+func (r *NamespaceLabelReconciler) enqueueNamespace(ctx context.Context, namespace string) error {
+	select {
+	case r.namespaceEvents <- event.GenericEvent{Object: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Get how long until the first of the NamespaceLabels is synced again when nothing changed, zero when none of them is
+func (r *NamespaceLabelReconciler) nextResync(namespaceLabels []*idandanielv1.NamespaceLabel) time.Duration {
+	var next time.Duration
+	for _, namespaceLabel := range namespaceLabels {
+		if after := r.resyncAfter(namespaceLabel); after > 0 && (next == 0 || after < next) {
+			next = after
+		}
+	}
+	return next
+}
+
+// Get how long until the NamespaceLabel is synced again when nothing changed, with jitter. Zero when it is only
+// synced on changes.
+func (r *NamespaceLabelReconciler) resyncAfter(namespaceLabel *idandanielv1.NamespaceLabel) time.Duration {
+	interval := r.ResyncPeriod
+	if namespaceLabel.Spec.ResyncInterval != nil && namespaceLabel.Spec.ResyncInterval.Duration > 0 {
+		interval = namespaceLabel.Spec.ResyncInterval.Duration
+	}
+	if interval <= 0 {
+		return 0
+	}
+	return wait.Jitter(interval, resyncJitter)
+}
+
+// Map a NamespaceLabel to its Namespace
+func namespaceOf(namespaceLabel client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: namespaceLabel.GetNamespace()}}}
+}
+
+// Get a request for every Namespace of the NamespaceLabels, once per Namespace
+func namespaceRequests(namespaceLabels *idandanielv1.NamespaceLabelList) []reconcile.Request {
+	var requests []reconcile.Request
+	for _, namespaceLabel := range namespaceLabels.Items {
+		request := reconcile.Request{NamespacedName: types.NamespacedName{Name: namespaceLabel.GetNamespace()}}
+		if !slices.Contains(requests, request) {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+// Map a NamespaceLabelPolicy to the Namespace of every NamespaceLabel, a policy may stop or start selecting any Namespace
+func (r *NamespaceLabelReconciler) allNamespaceLabels(policy client.Object) []reconcile.Request {
+	namespaceLabelList := &idandanielv1.NamespaceLabelList{}
+	if err := r.List(context.Background(), namespaceLabelList); err != nil {
+		ctrl.Log.WithName("namespacelabel").Error(err, "Failed to list NamespaceLabels", NamespaceLabelPolicyField, policy.GetName())
+		return nil
+	}
+
+	return namespaceRequests(namespaceLabelList)
+}
+
+// Only label and annotation changes of existing Namespaces can drift from the NamespaceLabels
+func namespaceMetadataChanged() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc:  func(event.CreateEvent) bool { return false },
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return predicate.LabelChangedPredicate{}.Update(e) || predicate.AnnotationChangedPredicate{}.Update(e)
+		},
+	}
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *NamespaceLabelReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &idandanielv1.NamespaceLabel{}, labelsFromIndex, indexLabelsFrom); err != nil {
+		return err
+	}
+
+	// Reconciled by Namespace, the events of the NamespaceLabels are mapped to their Namespace and the syncs requested
+	// by other controllers are queued as generic events of the Namespace
+	r.namespaceEvents = make(chan event.GenericEvent)
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&idandanielv1.NamespaceLabel{}).
+		Named("namespacelabel").
+		For(&corev1.Namespace{}, builder.WithPredicates(namespaceMetadataChanged())).
+		Watches(
+			&source.Channel{Source: r.namespaceEvents},
+			&handler.EnqueueRequestForObject{},
+		).
+		Watches(
+			&source.Kind{Type: &idandanielv1.NamespaceLabel{}},
+			handler.EnqueueRequestsFromMapFunc(namespaceOf),
+		).
+		Watches(
+			&source.Kind{Type: &idandanielv1.NamespaceLabelPolicy{}},
+			handler.EnqueueRequestsFromMapFunc(r.allNamespaceLabels),
+		).
+		// Only the metadata of the ConfigMaps is cached, their data is read by the SourceReader
+		Watches(
+			&source.Kind{Type: &corev1.ConfigMap{}},
+			handler.EnqueueRequestsFromMapFunc(r.namespaceLabelsForSource),
+			builder.OnlyMetadata,
+		).
 		Complete(r)
 }
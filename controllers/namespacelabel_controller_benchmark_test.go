@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// alwaysWritingClient hides the managed fields of the Namespaces it gets, so the reconciler never finds its keys applied
+// and writes the Namespace on every reconcile, the baseline of the benchmarks
+type alwaysWritingClient struct {
+	*countingClient
+}
+
+func (c *alwaysWritingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := c.countingClient.Get(ctx, key, obj, opts...); err != nil {
+		return err
+	}
+	if namespace, ok := obj.(*corev1.Namespace); ok {
+		namespace.SetManagedFields(nil)
+	}
+	return nil
+}
+
+// Build a reconciler of a Namespace with the given number of NamespaceLabels, each setting its own label
+func newCountingReconciler(namespace string, namespaceLabels int) (*NamespaceLabelReconciler, *countingClient) {
+	objects := []client.Object{&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}}
+	for i := 0; i < namespaceLabels; i++ {
+		objects = append(objects, &idandanielv1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("label-%d", i), Namespace: namespace},
+			Spec:       idandanielv1.NamespaceLabelSpec{Labels: map[string]string{fmt.Sprintf("label-%d", i): "value"}},
+		})
+	}
+
+	reconciler := newTestReconciler(objects...)
+	counting := &countingClient{Client: reconciler.Client}
+	reconciler.Client = counting
+	reconciler.Recorder = &record.FakeRecorder{}
+	return reconciler, counting
+}
+
+// BenchmarkReconcile reports the API writes of the reconciles of a Namespace with 20 NamespaceLabels. Once synced,
+// the Namespace is only written again when its labels drift, the baseline reconciler never finds the Namespace up to
+// date and writes it on every reconcile.
+func BenchmarkReconcile(b *testing.B) {
+	const Namespace = "benchmark"
+	ctx := context.Background()
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: Namespace}}
+
+	for _, bench := range []struct{ baseline, drift bool }{{true, false}, {false, false}, {true, true}, {false, true}} {
+		baseline, drift := bench.baseline, bench.drift
+		b.Run(fmt.Sprintf("baseline=%t/drift=%t", baseline, drift), func(b *testing.B) {
+			reconciler, counting := newCountingReconciler(Namespace, 20)
+			if baseline {
+				reconciler.Client = &alwaysWritingClient{countingClient: counting}
+			}
+			if _, err := reconciler.Reconcile(ctx, request); err != nil {
+				b.Fatal(err)
+			}
+			counting.writes = 0
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if drift {
+					b.StopTimer()
+					namespace := &corev1.Namespace{}
+					if err := counting.Client.Get(ctx, types.NamespacedName{Name: Namespace}, namespace); err != nil {
+						b.Fatal(err)
+					}
+					delete(namespace.Labels, "label-0")
+					if err := counting.Client.Update(ctx, namespace); err != nil {
+						b.Fatal(err)
+					}
+					b.StartTimer()
+				}
+				if _, err := reconciler.Reconcile(ctx, request); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(counting.writes)/float64(b.N), "writes/op")
+		})
+	}
+}
@@ -3,14 +3,18 @@ package controllers
 import (
 	"context"
 	"math/rand"
+	"reflect"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"golang.org/x/exp/maps"
 	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -38,8 +42,9 @@ func deleteNamespace(ctx context.Context, namespace string) {
 
 func startReconcile(ctx context.Context, request reconcile.Request) {
 	namespaceLabelReconciler := &NamespaceLabelReconciler{
-		Client: k8sClient,
-		Scheme: k8sClient.Scheme(),
+		Client:   k8sClient,
+		Scheme:   k8sClient.Scheme(),
+		Recorder: &record.FakeRecorder{},
 	}
 	_, err := namespaceLabelReconciler.Reconcile(ctx, request)
 	Expect(err).To(Not(HaveOccurred()))
@@ -81,6 +86,16 @@ func ensureLabelsDoesNotExist(ctx context.Context, namespace string, labels map[
 	}, Duration, Interval).Should(BeTrue())
 }
 
+func ensureStatusReady(ctx context.Context, namespaceLabelLookupKey types.NamespacedName, appliedLabels map[string]string) {
+	Eventually(func() bool {
+		namespaceLabel := &idandanielv1.NamespaceLabel{}
+		Expect(k8sClient.Get(ctx, namespaceLabelLookupKey, namespaceLabel)).To(Not(HaveOccurred()))
+
+		return meta.IsStatusConditionTrue(namespaceLabel.Status.Conditions, idandanielv1.ConditionReady) &&
+			reflect.DeepEqual(namespaceLabel.Status.AppliedLabels, appliedLabels)
+	}, Duration, Interval).Should(BeTrue())
+}
+
 var _ = Describe("NamespaceLabel controller test", Ordered, func() {
 
 	ctx := context.Background()
@@ -89,9 +104,15 @@ var _ = Describe("NamespaceLabel controller test", Ordered, func() {
 	managementLabels := map[string]string{
 		"app.kubernetes.io/name": Namespace,
 	}
+	foreignLabels := map[string]string{
+		"set-by-others": "value",
+	}
 
 	BeforeAll(func() {
-		createNamespace(ctx, Namespace, managementLabels)
+		namespaceLabels := map[string]string{}
+		maps.Copy(namespaceLabels, managementLabels)
+		maps.Copy(namespaceLabels, foreignLabels)
+		createNamespace(ctx, Namespace, namespaceLabels)
 	})
 
 	AfterAll(func() {
@@ -132,13 +153,16 @@ var _ = Describe("NamespaceLabel controller test", Ordered, func() {
 			}, Duration, Interval).Should(Succeed())
 
 			By("Reconciling the custom resource created")
-			startReconcile(ctx, reconcile.Request{NamespacedName: namespaceLabelLookupKey})
+			startReconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: Namespace}})
 
 			By("Ensuring Namespace management Labels still exist")
 			ensureLabelsExists(ctx, Namespace, managementLabels)
 
 			By("Ensuring NamespaceLabel's Labels were added")
 			ensureLabelsExists(ctx, Namespace, newLabels)
+
+			By("Ensuring NamespaceLabel's status reports the applied Labels")
+			ensureStatusReady(ctx, namespaceLabelLookupKey, newLabels)
 		})
 	})
 
@@ -175,13 +199,150 @@ var _ = Describe("NamespaceLabel controller test", Ordered, func() {
 			Expect(k8sClient.Update(ctx, nlToUpdate)).To(Not(HaveOccurred()))
 
 			By("Reconciling the CR updated")
-			startReconcile(ctx, reconcile.Request{NamespacedName: namespaceLabelLookupKey})
+			startReconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: Namespace}})
 
 			By("Ensuring Namespace management Labels still exist")
 			ensureLabelsExists(ctx, Namespace, managementLabels)
 
 			By("Ensuring NamespaceLabel's Labels were updated")
 			ensureLabelsExists(ctx, Namespace, newLabels)
+
+			By("Ensuring Labels set by others still exist")
+			ensureLabelsExists(ctx, Namespace, foreignLabels)
+		})
+	})
+
+	Context("When Namespace Labels drift", func() {
+
+		NamespaceLabelName := "test-drift-nl"
+
+		It("Should enforce the NamespaceLabel's Labels again.", func() {
+			By("Creating the custom resource for the Kind NamespaceLabel")
+			driftLabels := map[string]string{
+				"drift": "value",
+			}
+			namespaceLabel := &idandanielv1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      NamespaceLabelName,
+					Namespace: Namespace,
+				},
+				Spec: idandanielv1.NamespaceLabelSpec{
+					Labels: driftLabels,
+				},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Not(HaveOccurred()))
+
+			By("Reconciling the custom resource created")
+			startReconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: Namespace}})
+			ensureLabelsExists(ctx, Namespace, driftLabels)
+
+			By("Removing the Label from the Namespace directly")
+			n := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: Namespace}, n)).To(Not(HaveOccurred()))
+			delete(n.Labels, "drift")
+			Expect(k8sClient.Update(ctx, n)).To(Not(HaveOccurred()))
+
+			By("Ensuring the Label was enforced again by the controller")
+			ensureLabelsExists(ctx, Namespace, driftLabels)
+		})
+
+		It("Should set back a value changed by another field manager", func() {
+			By("Changing the Label value with another field manager")
+			n := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: Namespace}, n)).To(Not(HaveOccurred()))
+			n.Labels["drift"] = "edited"
+			Expect(k8sClient.Update(ctx, n, client.FieldOwner("kubectl-label"))).To(Not(HaveOccurred()))
+
+			By("Reconciling the Namespace")
+			startReconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: Namespace}})
+
+			By("Ensuring the Label was set back to the NamespaceLabel value")
+			ensureLabelsExists(ctx, Namespace, map[string]string{"drift": "value"})
+		})
+
+		It("Should report a Label another field manager set before without overriding it", func() {
+			By("Setting the Label with another field manager")
+			n := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: Namespace}, n)).To(Not(HaveOccurred()))
+			n.Labels["preset"] = "manual"
+			Expect(k8sClient.Update(ctx, n, client.FieldOwner("kubectl-label"))).To(Not(HaveOccurred()))
+
+			By("Adding the Label to the NamespaceLabel")
+			namespaceLabel := &idandanielv1.NamespaceLabel{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: NamespaceLabelName, Namespace: Namespace}, namespaceLabel)).To(Not(HaveOccurred()))
+			namespaceLabel.Spec.Labels["preset"] = "wanted"
+			Expect(k8sClient.Update(ctx, namespaceLabel)).To(Not(HaveOccurred()))
+
+			By("Reconciling the Namespace")
+			startReconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: Namespace}})
+
+			By("Ensuring the Label keeps the value of the other field manager and is reported as skipped")
+			ensureLabelsExists(ctx, Namespace, map[string]string{"preset": "manual", "drift": "value"})
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: NamespaceLabelName, Namespace: Namespace}, namespaceLabel)).To(Not(HaveOccurred()))
+			Expect(namespaceLabel.Status.SkippedLabels).Should(ContainElement(HaveField("Reason", idandanielv1.ReasonOwnershipConflict)))
+		})
+
+		It("Should override the Label when forcing the label ownership", func() {
+			By("Reconciling the Namespace with forced label ownership")
+			namespaceLabelReconciler := &NamespaceLabelReconciler{
+				Client:         k8sClient,
+				Scheme:         k8sClient.Scheme(),
+				Recorder:       &record.FakeRecorder{},
+				ForceOwnership: true,
+			}
+			_, err := namespaceLabelReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: Namespace}})
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Ensuring the Label was set to the NamespaceLabel value")
+			ensureLabelsExists(ctx, Namespace, map[string]string{"preset": "wanted"})
+		})
+	})
+
+	Context("When another field manager takes the Labels over", func() {
+
+		NamespaceLabelName := "test-takeover-nl"
+		takeoverLabels := map[string]string{
+			"takeover": "value",
+		}
+
+		It("Should apply the Labels again once the values are back.", func() {
+			By("Creating the custom resource for the Kind NamespaceLabel")
+			namespaceLabel := &idandanielv1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      NamespaceLabelName,
+					Namespace: Namespace,
+				},
+				Spec: idandanielv1.NamespaceLabelSpec{
+					Labels: takeoverLabels,
+				},
+			}
+			Expect(k8sClient.Create(ctx, namespaceLabel)).To(Not(HaveOccurred()))
+			startReconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: Namespace}})
+			ensureLabelsExists(ctx, Namespace, takeoverLabels)
+
+			By("Changing the Label with another field manager and setting its value back")
+			for _, value := range []string{"edited", "value"} {
+				n := &corev1.Namespace{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: Namespace}, n)).To(Not(HaveOccurred()))
+				n.Labels["takeover"] = value
+				Expect(k8sClient.Update(ctx, n, client.FieldOwner("kubectl-label"))).To(Not(HaveOccurred()))
+			}
+
+			By("Reconciling the Namespace")
+			startReconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: Namespace}})
+
+			By("Ensuring the controller applies the Label again")
+			Eventually(func() string {
+				n := &corev1.Namespace{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: Namespace}, n)).To(Not(HaveOccurred()))
+				for _, entry := range n.ManagedFields {
+					if entry.Manager == FieldManager && entry.Operation == metav1.ManagedFieldsOperationApply && entry.FieldsV1 != nil {
+						return string(entry.FieldsV1.Raw)
+					}
+				}
+				return ""
+			}, Duration, Interval).Should(ContainSubstring(`"f:takeover"`))
+			ensureLabelsExists(ctx, Namespace, takeoverLabels)
 		})
 	})
 
@@ -241,8 +402,7 @@ var _ = Describe("NamespaceLabel controller test", Ordered, func() {
 			}, Duration, Interval).Should(Succeed())
 
 			By("Reconciling to apply changes")
-			startReconcile(ctx, reconcile.Request{NamespacedName: toDeleteLookupKey})
-			startReconcile(ctx, reconcile.Request{NamespacedName: toKeepLookupKey})
+			startReconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: Namespace}})
 
 			By("Ensuring Namespace management Labels still exist")
 			ensureLabelsExists(ctx, Namespace, managementLabels)
@@ -263,7 +423,7 @@ var _ = Describe("NamespaceLabel controller test", Ordered, func() {
 			}, Duration, Interval).Should(Not(Succeed()))
 
 			By("Reconciling the CR deleted")
-			startReconcile(ctx, reconcile.Request{NamespacedName: toDeleteLookupKey})
+			startReconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: Namespace}})
 
 			By("Ensuring Namespace management Labels still exist")
 			ensureLabelsExists(ctx, Namespace, managementLabels)
@@ -1,12 +1,26 @@
 package controllers
 
 import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+	"idandaniel.io/namespacelabel-demo/common/protected"
 	"idandaniel.io/namespacelabel-demo/common/wrappers"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 var _ = Describe("NamespaceLabel Controller", func() {
@@ -158,6 +172,9 @@ var _ = Describe("NamespaceLabel Controller", func() {
 					Remove:        Remove,
 					Keep:          Keep,
 				},
+				Annotations: map[string]string{
+					wrappers.OwnedLabelsAnnotation: strings.Join([]string{Keep, Remove}, ","),
+				},
 			},
 		}
 		wrappedNamespace := &wrappers.NamespaceWrapper{Namespace: namespace}
@@ -174,6 +191,7 @@ var _ = Describe("NamespaceLabel Controller", func() {
 			}
 
 			Expect(wrappedNamespace.Labels).Should(Equal(expectedLabels))
+			Expect(wrappedNamespace.OwnedLabels()).Should(Equal([]string{Keep}))
 		})
 	})
 
@@ -190,6 +208,9 @@ var _ = Describe("NamespaceLabel Controller", func() {
 					ManagementKey: NamespaceLabelName,
 					Old:           Old,
 				},
+				Annotations: map[string]string{
+					wrappers.OwnedLabelsAnnotation: Old,
+				},
 			},
 		}
 		wrappedNamespace := &wrappers.NamespaceWrapper{Namespace: namespace}
@@ -206,6 +227,1607 @@ var _ = Describe("NamespaceLabel Controller", func() {
 			}
 
 			Expect(wrappedNamespace.Labels).Should(Equal(expectedLabels))
+			Expect(wrappedNamespace.OwnedLabels()).Should(Equal([]string{New}))
+		})
+	})
+
+	Context("With labels set by others", func() {
+		const (
+			Foreign = "FOREIGN"
+			Owned   = "OWNED"
+		)
+
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: NamespaceLabelName,
+				Labels: map[string]string{
+					Foreign: Foreign,
+					Owned:   Owned,
+				},
+				Annotations: map[string]string{
+					wrappers.OwnedLabelsAnnotation: Owned,
+				},
+			},
+		}
+		wrappedNamespace := &wrappers.NamespaceWrapper{Namespace: namespace}
+
+		It("Should keep labels it does not own when updating", func() {
+			wrappedNamespace.UpdateLabels(true, map[string]string{})
+
+			Expect(wrappedNamespace.Labels).Should(Equal(map[string]string{Foreign: Foreign}))
+			Expect(wrappedNamespace.OwnedLabels()).Should(BeEmpty())
+			Expect(wrappedNamespace.Annotations).ShouldNot(HaveKey(wrappers.OwnedLabelsAnnotation))
+		})
+
+		It("Should keep labels it does not own when removing", func() {
+			wrappedNamespace.RemoveLabelsExcept(map[string]string{Foreign: Foreign}, nil)
+
+			Expect(wrappedNamespace.Labels).Should(Equal(map[string]string{Foreign: Foreign}))
+		})
+	})
+
+	Context("With labels overriding the values set by others", func() {
+		const (
+			Team    = "team"
+			Tier    = "tier"
+			Foreign = "foreign"
+		)
+
+		newWrappedNamespace := func() *wrappers.NamespaceWrapper {
+			return &wrappers.NamespaceWrapper{Namespace: &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   NamespaceLabelName,
+					Labels: map[string]string{Team: Foreign},
+				},
+			}}
+		}
+
+		It("Should keep the previous values of the overridden labels", func() {
+			wrappedNamespace := newWrappedNamespace()
+			wrappedNamespace.UpdateLabels(true, map[string]string{Team: "payments", Tier: "gold"})
+
+			Expect(wrappedNamespace.Labels).Should(Equal(map[string]string{Team: "payments", Tier: "gold"}))
+			Expect(wrappedNamespace.PreviousLabels()).Should(Equal(map[string]string{Team: Foreign}))
+			Expect(wrappedNamespace.ApplyConfiguration().Annotations).Should(HaveKeyWithValue(wrappers.PreviousLabelsAnnotation, `{"team":"foreign"}`))
+
+			wrappedNamespace.UpdateLabels(true, map[string]string{Team: "platform", Tier: "gold"})
+			Expect(wrappedNamespace.PreviousLabels()).Should(Equal(map[string]string{Team: Foreign}))
+		})
+
+		It("Should restore the previous values when removing the labels", func() {
+			wrappedNamespace := newWrappedNamespace()
+			wrappedNamespace.UpdateLabels(true, map[string]string{Team: "payments", Tier: "gold"})
+			wrappedNamespace.RemoveLabelsExcept(map[string]string{Team: "payments", Tier: "gold"}, nil)
+
+			Expect(wrappedNamespace.Labels).Should(Equal(map[string]string{Team: Foreign}))
+			Expect(wrappedNamespace.OwnedLabels()).Should(BeEmpty())
+			Expect(wrappedNamespace.RestoredLabels()).Should(Equal(map[string]string{Team: Foreign}))
+			Expect(wrappedNamespace.Annotations).ShouldNot(HaveKey(wrappers.PreviousLabelsAnnotation))
+		})
+
+		It("Should restore the previous values of the labels no longer wanted", func() {
+			wrappedNamespace := newWrappedNamespace()
+			wrappedNamespace.UpdateLabels(true, map[string]string{Team: "payments", Tier: "gold"})
+			wrappedNamespace.UpdateLabels(true, map[string]string{Tier: "gold"})
+
+			Expect(wrappedNamespace.Labels).Should(Equal(map[string]string{Team: Foreign, Tier: "gold"}))
+			Expect(wrappedNamespace.OwnedLabels()).Should(Equal([]string{Tier}))
+			Expect(wrappedNamespace.RestoredLabels()).Should(Equal(map[string]string{Team: Foreign}))
+			Expect(wrappedNamespace.PreviousLabels()).Should(BeEmpty())
+		})
+
+		It("Should keep the previous values until the labels are restored", func() {
+			ctx := context.Background()
+			current := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:   NamespaceLabelName,
+				Labels: map[string]string{Team: "payments"},
+				Annotations: map[string]string{
+					wrappers.OwnedLabelsAnnotation:    Team,
+					wrappers.PreviousLabelsAnnotation: `{"team":"foreign"}`,
+				},
+			}}
+			failing := &failingApplyClient{countingClient: countingClient{Client: newFakeClient(current)}}
+			reconciler := &NamespaceLabelReconciler{Client: failing, Scheme: failing.Scheme()}
+
+			wrappedNamespace := reconciler.wrapNamespace(current.DeepCopy())
+			wrappedNamespace.UpdateLabels(true, map[string]string{})
+			_, err := reconciler.applyNamespace(ctx, current, wrappedNamespace)
+			Expect(apierrors.IsServiceUnavailable(err)).Should(BeTrue())
+
+			// The label was restored before the failed apply, which would have dropped its previous value
+			namespace := &corev1.Namespace{}
+			Expect(failing.Get(ctx, types.NamespacedName{Name: NamespaceLabelName}, namespace)).To(Succeed())
+			Expect(namespace.Labels).Should(Equal(map[string]string{Team: Foreign}))
+			Expect(namespace.Annotations).Should(HaveKeyWithValue(wrappers.PreviousLabelsAnnotation, `{"team":"foreign"}`))
+		})
+
+		It("Should only report a conflict when overriding a value another field manager owns", func() {
+			ctx := context.Background()
+
+			// The team label was set with kubectl before the NamespaceLabel existed
+			conflicting := &conflictingClient{
+				countingClient: countingClient{Client: newFakeClient(
+					&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: NamespaceLabelName, Labels: map[string]string{Team: Foreign}}},
+					&idandanielv1.NamespaceLabel{
+						ObjectMeta: metav1.ObjectMeta{Name: "labels", Namespace: NamespaceLabelName, Finalizers: []string{finalizer}},
+						Spec:       idandanielv1.NamespaceLabelSpec{Labels: map[string]string{Team: "payments"}},
+					},
+				)},
+				managedByOthers: map[string]string{Team: Foreign},
+			}
+			reconciler := &NamespaceLabelReconciler{Client: conflicting, Scheme: conflicting.Scheme(), Recorder: &record.FakeRecorder{}}
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: NamespaceLabelName}})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			namespace := &corev1.Namespace{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: NamespaceLabelName}, namespace)).To(Succeed())
+			Expect(namespace.Labels).Should(Equal(map[string]string{Team: Foreign}))
+			Expect(namespace.Annotations).ShouldNot(HaveKey(wrappers.PreviousLabelsAnnotation))
+
+			namespaceLabel := &idandanielv1.NamespaceLabel{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "labels", Namespace: NamespaceLabelName}, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.SkippedLabels).Should(ConsistOf(HaveField("Reason", idandanielv1.ReasonOwnershipConflict)))
+		})
+
+		It("Should report the restored labels in the events", func() {
+			recorder := record.NewFakeRecorder(10)
+			reconciler := &NamespaceLabelReconciler{Recorder: recorder}
+			changes := labelChanges(map[string]string{Team: "payments", Tier: "gold"}, nil).withRestored(map[string]string{Team: Foreign})
+
+			reconciler.recordChanges(namespace, NamespaceLabelName, changes)
+			Expect(recorder.Events).Should(Receive(Equal("Normal LabelsRemoved Removed labels from Namespace " + NamespaceLabelName + ": tier")))
+			Expect(recorder.Events).Should(Receive(Equal("Normal LabelsRestored Restored the previous values of labels on Namespace " + NamespaceLabelName + ": team=foreign")))
+		})
+	})
+
+	Context("With server-side apply", func() {
+		const (
+			Foreign = "FOREIGN"
+			Owned   = "OWNED"
+		)
+
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            NamespaceLabelName,
+				ResourceVersion: "42",
+				Labels: map[string]string{
+					ManagementKey: NamespaceLabelName,
+					Foreign:       Foreign,
+				},
+				Annotations: map[string]string{
+					"set-by-others": "value",
+				},
+			},
+		}
+		wrappedNamespace := &wrappers.NamespaceWrapper{Namespace: namespace}
+
+		It("Should only apply the owned labels", func() {
+			wrappedNamespace.UpdateLabels(true, map[string]string{Owned: Owned})
+
+			applyConfiguration := wrappedNamespace.ApplyConfiguration()
+			Expect(applyConfiguration.APIVersion).Should(Equal("v1"))
+			Expect(applyConfiguration.Kind).Should(Equal("Namespace"))
+			Expect(applyConfiguration.Name).Should(Equal(NamespaceLabelName))
+			Expect(applyConfiguration.ResourceVersion).Should(BeEmpty())
+			Expect(applyConfiguration.Labels).Should(Equal(map[string]string{Owned: Owned}))
+			Expect(applyConfiguration.Annotations).Should(Equal(map[string]string{wrappers.OwnedLabelsAnnotation: Owned}))
+		})
+
+		It("Should apply no labels when none are owned", func() {
+			wrappedNamespace.UpdateLabels(true, map[string]string{})
+
+			applyConfiguration := wrappedNamespace.ApplyConfiguration()
+			Expect(applyConfiguration.Labels).Should(BeEmpty())
+			Expect(applyConfiguration.Annotations).Should(BeEmpty())
+		})
+
+		It("Should get the keys of a server-side apply conflict", func() {
+			labels, annotations := conflictingKeys(newApplyConflict(
+				".metadata.labels.team", ".metadata.labels.app.kubernetes.io/part-of", ".metadata.annotations.owner",
+			))
+			Expect(labels).Should(Equal([]string{"team", "app.kubernetes.io/part-of"}))
+			Expect(annotations).Should(Equal([]string{"owner"}))
+
+			labels, annotations = conflictingKeys(errors.New("timeout"))
+			Expect(labels).Should(BeEmpty())
+			Expect(annotations).Should(BeEmpty())
+		})
+
+		It("Should set the owned keys back and report the conflicting keys it never owned", func() {
+			const ConflictNamespace = "ownership-conflict"
+			ctx := context.Background()
+
+			// The team label was overwritten with kubectl after the controller applied it, the cost label was set with
+			// kubectl before the NamespaceLabel wanted it
+			conflicting := &conflictingClient{
+				countingClient: countingClient{Client: newFakeClient(
+					&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+						Name:        ConflictNamespace,
+						Labels:      map[string]string{"team": "manual", "tier": "gold", "cost": "manual"},
+						Annotations: map[string]string{wrappers.OwnedLabelsAnnotation: "team,tier"},
+					}},
+					&idandanielv1.NamespaceLabel{
+						ObjectMeta: metav1.ObjectMeta{Name: "labels", Namespace: ConflictNamespace, Finalizers: []string{finalizer}},
+						Spec: idandanielv1.NamespaceLabelSpec{
+							Labels:      map[string]string{"team": "payments", "tier": "gold", "env": "prod", "cost": "shared"},
+							Annotations: map[string]string{"owner": "payments"},
+						},
+					},
+				)},
+				managedByOthers: map[string]string{"team": "manual", "cost": "manual"},
+			}
+			reconciler := &NamespaceLabelReconciler{Client: conflicting, Scheme: conflicting.Scheme(), Recorder: &record.FakeRecorder{}}
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: ConflictNamespace}})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			namespace := &corev1.Namespace{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: ConflictNamespace}, namespace)).To(Succeed())
+			Expect(namespace.Labels).Should(Equal(map[string]string{"team": "payments", "tier": "gold", "env": "prod", "cost": "manual"}))
+			Expect(namespace.Annotations).Should(HaveKeyWithValue("owner", "payments"))
+			Expect(namespace.Annotations).Should(HaveKeyWithValue(wrappers.OwnedLabelsAnnotation, "env,team,tier"))
+			Expect(conflicting.managedByOthers).Should(Equal(map[string]string{"cost": "manual"}))
+
+			namespaceLabel := &idandanielv1.NamespaceLabel{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "labels", Namespace: ConflictNamespace}, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.AppliedLabels).Should(Equal(map[string]string{"team": "payments", "tier": "gold", "env": "prod"}))
+			Expect(namespaceLabel.Status.AppliedAnnotations).Should(Equal(map[string]string{"owner": "payments"}))
+			Expect(namespaceLabel.Status.SkippedLabels).Should(Equal([]idandanielv1.SkippedLabel{{
+				Key:     "cost",
+				Value:   "shared",
+				Reason:  idandanielv1.ReasonOwnershipConflict,
+				Message: "The label is managed by others with a different value",
+			}}))
+			Expect(meta.IsStatusConditionTrue(namespaceLabel.Status.Conditions, idandanielv1.ConditionSynced)).Should(BeTrue())
+		})
+	})
+
+	Context("With annotations", func() {
+		const (
+			Foreign    = "FOREIGN"
+			Owned      = "OWNED"
+			NodeSelect = "scheduler.alpha.kubernetes.io/node-selector"
+			LastApply  = "kubectl.kubernetes.io/last-applied-configuration"
+		)
+
+		newWrappedNamespace := func() *wrappers.NamespaceWrapper {
+			return &wrappers.NamespaceWrapper{Namespace: &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   NamespaceLabelName,
+					Labels: map[string]string{Owned: Owned},
+					Annotations: map[string]string{
+						Foreign:                             Foreign,
+						Owned:                               Owned,
+						LastApply:                           "{}",
+						wrappers.OwnedLabelsAnnotation:      Owned,
+						wrappers.OwnedAnnotationsAnnotation: Owned,
+					},
+				},
+			}}
+		}
+
+		It("Should merge annotations and keep the ones it does not own", func() {
+			wrappedNamespace := newWrappedNamespace()
+			wrappedNamespace.UpdateAnnotations(true, map[string]string{NodeSelect: "env=prod"})
+
+			Expect(wrappedNamespace.Annotations).Should(Equal(map[string]string{
+				Foreign:                             Foreign,
+				NodeSelect:                          "env=prod",
+				LastApply:                           "{}",
+				wrappers.OwnedLabelsAnnotation:      Owned,
+				wrappers.OwnedAnnotationsAnnotation: NodeSelect,
+			}))
+			Expect(wrappedNamespace.OwnedAnnotations()).Should(Equal([]string{NodeSelect}))
+			Expect(wrappedNamespace.OwnedLabels()).Should(Equal([]string{Owned}))
+		})
+
+		It("Should never change protected annotations", func() {
+			wrappedNamespace := newWrappedNamespace()
+			wrappedNamespace.UpdateAnnotations(true, map[string]string{
+				LastApply:                      "changed",
+				wrappers.OwnedLabelsAnnotation: "changed",
+			})
+
+			Expect(wrappedNamespace.Annotations).Should(HaveKeyWithValue(LastApply, "{}"))
+			Expect(wrappedNamespace.Annotations).Should(HaveKeyWithValue(wrappers.OwnedLabelsAnnotation, Owned))
+			Expect(wrappedNamespace.OwnedAnnotations()).Should(BeEmpty())
+		})
+
+		It("Should only remove owned annotations", func() {
+			wrappedNamespace := newWrappedNamespace()
+			wrappedNamespace.RemoveAnnotationsExcept(map[string]string{Owned: Owned, Foreign: Foreign}, nil)
+
+			Expect(wrappedNamespace.Annotations).ShouldNot(HaveKey(Owned))
+			Expect(wrappedNamespace.Annotations).Should(HaveKeyWithValue(Foreign, Foreign))
+			Expect(wrappedNamespace.Annotations).ShouldNot(HaveKey(wrappers.OwnedAnnotationsAnnotation))
+		})
+
+		It("Should apply the owned labels and annotations", func() {
+			applyConfiguration := newWrappedNamespace().ApplyConfiguration()
+
+			Expect(applyConfiguration.Labels).Should(Equal(map[string]string{Owned: Owned}))
+			Expect(applyConfiguration.Annotations).Should(Equal(map[string]string{
+				Owned:                               Owned,
+				wrappers.OwnedLabelsAnnotation:      Owned,
+				wrappers.OwnedAnnotationsAnnotation: Owned,
+			}))
+		})
+
+		It("Should resolve and report conflicting annotations", func() {
+			older := idandanielv1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: "older", Namespace: NamespaceLabelName, CreationTimestamp: metav1.NewTime(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))},
+				Spec:       idandanielv1.NamespaceLabelSpec{Annotations: map[string]string{NodeSelect: "env=prod"}},
+			}
+			newer := idandanielv1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: "newer", Namespace: NamespaceLabelName, CreationTimestamp: metav1.NewTime(time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC))},
+				Spec:       idandanielv1.NamespaceLabelSpec{Annotations: map[string]string{NodeSelect: "env=dev", LastApply: "{}"}},
+			}
+			namespaceLabels := &idandanielv1.NamespaceLabelList{Items: []idandanielv1.NamespaceLabel{newer, older}}
+
+			annotations, conflicts := namespaceLabels.ResolveAnnotations()
+			Expect(annotations).Should(Equal(map[string]string{NodeSelect: "env=prod", LastApply: "{}"}))
+
+			setStatus(&newer, &syncResult{
+				namespaceAnnotations: map[string]string{NodeSelect: "env=prod", LastApply: "{}"},
+				annotationConflicts:  conflicts,
+			})
+			Expect(newer.Status.AppliedAnnotations).Should(BeEmpty())
+			Expect(newer.Status.SkippedAnnotations).Should(Equal([]idandanielv1.SkippedLabel{
+				{
+					Key:     LastApply,
+					Value:   "{}",
+					Reason:  idandanielv1.ReasonLabelProtected,
+					Message: "The annotation is protected and can not be set by NamespaceLabels",
+				},
+				{
+					Key:     NodeSelect,
+					Value:   "env=dev",
+					Reason:  idandanielv1.ReasonLabelOverridden,
+					Message: "Overridden by NamespaceLabel older",
+				},
+			}))
+			Expect(meta.IsStatusConditionFalse(newer.Status.Conditions, idandanielv1.ConditionReady)).Should(BeTrue())
+		})
+	})
+
+	Context("With NamespaceLabel status", func() {
+		const (
+			Shared = "SHARED"
+			Own    = "OWN"
+		)
+
+		winner := idandanielv1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "NamespaceLabelWinner",
+				Namespace:         namespace.Name,
+				Generation:        2,
+				CreationTimestamp: metav1.NewTime(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)),
+			},
+			Spec: idandanielv1.NamespaceLabelSpec{
+				Labels: map[string]string{Shared: "winner"},
+			},
+		}
+		loser := idandanielv1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "NamespaceLabelLoser",
+				Namespace:         namespace.Name,
+				Generation:        3,
+				CreationTimestamp: metav1.NewTime(time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)),
+			},
+			Spec: idandanielv1.NamespaceLabelSpec{
+				Labels: map[string]string{Shared: "loser", Own: Own},
+			},
+		}
+		namespaceLabels := &idandanielv1.NamespaceLabelList{
+			Items: []idandanielv1.NamespaceLabel{winner, loser},
+		}
+		_, conflicts := namespaceLabels.ResolveLabels()
+		result := &syncResult{
+			namespaceLabels: map[string]string{
+				ManagementKey: NamespaceLabelName,
+				Shared:        "winner",
+				Own:           Own,
+			},
+			conflicts:       conflicts,
+			protectedLabels: protected.DefaultRules(),
+		}
+
+		It("Should report applied labels as Ready", func() {
+			setStatus(&winner, result)
+
+			Expect(winner.Status.ObservedGeneration).Should(Equal(int64(2)))
+			Expect(winner.Status.AppliedLabels).Should(Equal(map[string]string{Shared: "winner"}))
+			Expect(winner.Status.SkippedLabels).Should(BeEmpty())
+			Expect(meta.IsStatusConditionTrue(winner.Status.Conditions, idandanielv1.ConditionReady)).Should(BeTrue())
+			Expect(meta.IsStatusConditionTrue(winner.Status.Conditions, idandanielv1.ConditionSynced)).Should(BeTrue())
+			Expect(meta.IsStatusConditionFalse(winner.Status.Conditions, idandanielv1.ConditionConflicted)).Should(BeTrue())
+		})
+
+		It("Should report overridden labels as skipped", func() {
+			setStatus(&loser, result)
+
+			Expect(loser.Status.AppliedLabels).Should(Equal(map[string]string{Own: Own}))
+			Expect(loser.Status.SkippedLabels).Should(ConsistOf(idandanielv1.SkippedLabel{
+				Key:     Shared,
+				Value:   "loser",
+				Reason:  idandanielv1.ReasonLabelOverridden,
+				Message: "Overridden by NamespaceLabel NamespaceLabelWinner",
+			}))
+			Expect(meta.IsStatusConditionFalse(loser.Status.Conditions, idandanielv1.ConditionReady)).Should(BeTrue())
+			Expect(meta.IsStatusConditionTrue(loser.Status.Conditions, idandanielv1.ConditionConflicted)).Should(BeTrue())
+		})
+
+		It("Should report protected labels as skipped", func() {
+			protectedNamespaceLabel := winner.DeepCopy()
+			protectedNamespaceLabel.Spec.Labels = map[string]string{ManagementKey: "other"}
+			setStatus(protectedNamespaceLabel, result)
+
+			Expect(protectedNamespaceLabel.Status.AppliedLabels).Should(BeEmpty())
+			Expect(protectedNamespaceLabel.Status.SkippedLabels).Should(ConsistOf(idandanielv1.SkippedLabel{
+				Key:     ManagementKey,
+				Value:   "other",
+				Reason:  idandanielv1.ReasonLabelProtected,
+				Message: "The label is protected and can not be set by NamespaceLabels",
+			}))
+		})
+
+		It("Should report failed syncs", func() {
+			failedResult := *result
+			failedResult.err = errors.New("namespace update failed")
+			setStatus(&winner, &failedResult)
+
+			synced := meta.FindStatusCondition(winner.Status.Conditions, idandanielv1.ConditionSynced)
+			Expect(synced).ShouldNot(BeNil())
+			Expect(synced.Status).Should(Equal(metav1.ConditionFalse))
+			Expect(synced.Message).Should(Equal("namespace update failed"))
+			Expect(meta.IsStatusConditionFalse(winner.Status.Conditions, idandanielv1.ConditionReady)).Should(BeTrue())
+		})
+	})
+
+	Context("With conflicting NamespaceLabels", func() {
+		const Key = "CONFLICTED"
+
+		newNamespaceLabel := func(name string, age time.Duration, value string, policy idandanielv1.ConflictPolicy, priority int32) idandanielv1.NamespaceLabel {
+			return idandanielv1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              name,
+					Namespace:         namespace.Name,
+					CreationTimestamp: metav1.NewTime(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC).Add(-age)),
+				},
+				Spec: idandanielv1.NamespaceLabelSpec{
+					Labels:         map[string]string{Key: value},
+					ConflictPolicy: policy,
+					Priority:       priority,
+				},
+			}
+		}
+
+		It("Should let the oldest NamespaceLabel win regardless of list order", func() {
+			older := newNamespaceLabel("b-older", time.Hour, "older", idandanielv1.FirstWriterWins, 0)
+			newer := newNamespaceLabel("a-newer", time.Minute, "newer", idandanielv1.FirstWriterWins, 10)
+
+			for _, items := range [][]idandanielv1.NamespaceLabel{{older, newer}, {newer, older}} {
+				namespaceLabels := &idandanielv1.NamespaceLabelList{Items: items}
+				labels, conflicts := namespaceLabels.ResolveLabels()
+
+				Expect(labels).Should(Equal(map[string]string{Key: "older"}))
+				Expect(conflicts).Should(Equal(map[string][]idandanielv1.LabelConflict{
+					newer.Name: {{Key: Key, Value: "newer", Winner: older.Name, WinnerKind: "NamespaceLabel"}},
+				}))
+			}
+		})
+
+		It("Should break creation time ties by name", func() {
+			first := newNamespaceLabel("a-first", time.Hour, "first", idandanielv1.FirstWriterWins, 0)
+			second := newNamespaceLabel("b-second", time.Hour, "second", idandanielv1.FirstWriterWins, 0)
+
+			namespaceLabels := &idandanielv1.NamespaceLabelList{Items: []idandanielv1.NamespaceLabel{second, first}}
+			Expect(namespaceLabels.GetLabels()).Should(Equal(map[string]string{Key: "first"}))
+		})
+
+		It("Should let the highest priority win when all conflicting NamespaceLabels use priorities", func() {
+			older := newNamespaceLabel("older", time.Hour, "older", idandanielv1.HighestPriority, 1)
+			newer := newNamespaceLabel("newer", time.Minute, "newer", idandanielv1.HighestPriority, 5)
+
+			namespaceLabels := &idandanielv1.NamespaceLabelList{Items: []idandanielv1.NamespaceLabel{older, newer}}
+			labels, conflicts := namespaceLabels.ResolveLabels()
+
+			Expect(labels).Should(Equal(map[string]string{Key: "newer"}))
+			Expect(conflicts[older.Name]).Should(ConsistOf(idandanielv1.LabelConflict{Key: Key, Value: "older", Winner: newer.Name, WinnerKind: "NamespaceLabel"}))
+		})
+
+		It("Should ignore priorities when a conflicting NamespaceLabel is first writer wins", func() {
+			older := newNamespaceLabel("older", time.Hour, "older", idandanielv1.FirstWriterWins, 1)
+			newer := newNamespaceLabel("newer", time.Minute, "newer", idandanielv1.HighestPriority, 5)
+
+			namespaceLabels := &idandanielv1.NamespaceLabelList{Items: []idandanielv1.NamespaceLabel{older, newer}}
+			Expect(namespaceLabels.GetLabels()).Should(Equal(map[string]string{Key: "older"}))
+		})
+
+		It("Should not apply conflicting labels of refusing NamespaceLabels", func() {
+			older := newNamespaceLabel("older", time.Hour, "older", idandanielv1.Refuse, 0)
+			newer := newNamespaceLabel("newer", time.Minute, "newer", idandanielv1.FirstWriterWins, 0)
+			agreeing := newNamespaceLabel("agreeing", time.Second, "newer", idandanielv1.Refuse, 0)
+
+			namespaceLabels := &idandanielv1.NamespaceLabelList{Items: []idandanielv1.NamespaceLabel{older, newer, agreeing}}
+			labels, conflicts := namespaceLabels.ResolveLabels()
+
+			Expect(labels).Should(Equal(map[string]string{Key: "newer"}))
+			Expect(conflicts).Should(Equal(map[string][]idandanielv1.LabelConflict{
+				older.Name: {{Key: Key, Value: "older", Winner: newer.Name, WinnerKind: "NamespaceLabel", Refused: true}},
+			}))
+		})
+
+		It("Should not apply a label all the conflicting NamespaceLabels refuse", func() {
+			older := newNamespaceLabel("older", time.Hour, "older", idandanielv1.Refuse, 0)
+			newer := newNamespaceLabel("newer", time.Minute, "newer", idandanielv1.Refuse, 0)
+
+			namespaceLabels := &idandanielv1.NamespaceLabelList{Items: []idandanielv1.NamespaceLabel{older, newer}}
+			labels, conflicts := namespaceLabels.ResolveLabels()
+
+			Expect(labels).Should(BeEmpty())
+			Expect(conflicts).Should(HaveLen(2))
+		})
+	})
+
+	Context("With protected labels", func() {
+		rules, err := protected.NewRules(
+			[]string{"istio-injection"},
+			protected.DefaultPrefixes,
+			[]string{`argocd\.argoproj\.io/.*`},
+		)
+
+		It("Should build the rules", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Should match keys, DNS prefixes and patterns", func() {
+			Expect(rules.IsProtected("istio-injection")).Should(BeTrue())
+			Expect(rules.IsProtected("kubernetes.io/metadata.name")).Should(BeTrue())
+			Expect(rules.IsProtected("pod-security.kubernetes.io/enforce")).Should(BeTrue())
+			Expect(rules.IsProtected("argocd.argoproj.io/instance")).Should(BeTrue())
+
+			Expect(rules.IsProtected("istio-injection-disabled")).Should(BeFalse())
+			Expect(rules.IsProtected("notkubernetes.io-ish")).Should(BeFalse())
+			Expect(rules.IsProtected("notkubernetes.io/name")).Should(BeFalse())
+			Expect(rules.IsProtected("team")).Should(BeFalse())
+		})
+
+		It("Should reject invalid patterns", func() {
+			_, err := protected.NewRules(nil, nil, []string{"("})
+			Expect(err).Should(HaveOccurred())
+		})
+
+		It("Should load the rules from a ConfigMap", func() {
+			configMapRules, err := protected.RulesFromConfigMap(&corev1.ConfigMap{
+				Data: map[string]string{
+					protected.KeysField:     "team, owner",
+					protected.PrefixesField: "example.com\nexample.org",
+					protected.PatternsField: "legacy-.*",
+				},
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(configMapRules.Keys).Should(Equal([]string{"team", "owner"}))
+			Expect(configMapRules.Prefixes).Should(Equal([]string{"example.com", "example.org"}))
+			Expect(configMapRules.IsProtected("legacy-team")).Should(BeTrue())
+		})
+
+		It("Should keep protected labels and never overwrite them", func() {
+			namespace := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: NamespaceLabelName,
+					Labels: map[string]string{
+						"istio-injection":             "enabled",
+						"argocd.argoproj.io/instance": "tenant",
+						"notkubernetes.io-ish":        "value",
+					},
+					Annotations: map[string]string{
+						wrappers.OwnedLabelsAnnotation: "istio-injection,notkubernetes.io-ish",
+					},
+				},
+			}
+			wrappedNamespace := &wrappers.NamespaceWrapper{Namespace: namespace, ProtectedLabels: rules}
+
+			wrappedNamespace.UpdateLabels(true, map[string]string{
+				"istio-injection": "disabled",
+				"team":            "a",
+			})
+
+			Expect(wrappedNamespace.Labels).Should(Equal(map[string]string{
+				"istio-injection":             "enabled",
+				"argocd.argoproj.io/instance": "tenant",
+				"team":                        "a",
+			}))
+		})
+
+		It("Should never remove protected labels", func() {
+			namespace := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   NamespaceLabelName,
+					Labels: map[string]string{"istio-injection": "enabled"},
+				},
+			}
+			wrappedNamespace := &wrappers.NamespaceWrapper{Namespace: namespace, ProtectedLabels: rules}
+
+			wrappedNamespace.RemoveLabelsExcept(map[string]string{"istio-injection": "enabled"}, nil)
+
+			Expect(wrappedNamespace.Labels).Should(Equal(map[string]string{"istio-injection": "enabled"}))
+		})
+	})
+
+	Context("With ClusterNamespaceLabels", func() {
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "tenant-a",
+				Labels: map[string]string{"team": "payments"},
+			},
+		}
+
+		newClusterNamespaceLabel := func(name string, age time.Duration, selector idandanielv1.NamespaceSelector, labels map[string]string) idandanielv1.ClusterNamespaceLabel {
+			return idandanielv1.ClusterNamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              name,
+					CreationTimestamp: metav1.NewTime(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC).Add(-age)),
+				},
+				Spec: idandanielv1.ClusterNamespaceLabelSpec{NamespaceSelector: selector, Labels: labels},
+			}
+		}
+
+		It("Should select Namespaces by labels and name globs", func() {
+			for _, selector := range []idandanielv1.NamespaceSelector{
+				{},
+				{Names: []string{"other", "tenant-*"}},
+				{LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}}},
+				{LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}}, Names: []string{"tenant-a"}},
+			} {
+				Expect(selector.Matches(namespace)).Should(BeTrue())
+			}
+
+			for _, selector := range []idandanielv1.NamespaceSelector{
+				{Names: []string{"other-*"}},
+				{LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "search"}}},
+				{LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}}, Names: []string{"other"}},
+			} {
+				Expect(selector.Matches(namespace)).Should(BeFalse())
+			}
+		})
+
+		It("Should fail on invalid selectors", func() {
+			selector := idandanielv1.NamespaceSelector{Names: []string{"[tenant"}}
+			_, err := selector.Matches(namespace)
+			Expect(err).Should(HaveOccurred())
+		})
+
+		It("Should let the oldest selecting ClusterNamespaceLabel win", func() {
+			clusterNamespaceLabels := &idandanielv1.ClusterNamespaceLabelList{Items: []idandanielv1.ClusterNamespaceLabel{
+				newClusterNamespaceLabel("newer", time.Minute, idandanielv1.NamespaceSelector{}, map[string]string{"tier": "newer", "env": "prod"}),
+				newClusterNamespaceLabel("older", time.Hour, idandanielv1.NamespaceSelector{}, map[string]string{"tier": "older"}),
+				newClusterNamespaceLabel("oldest", 2*time.Hour, idandanielv1.NamespaceSelector{Names: []string{"other"}}, map[string]string{"tier": "oldest"}),
+			}}
+
+			labels, owners := clusterNamespaceLabels.GetLabelsFor(namespace)
+
+			Expect(labels).Should(Equal(map[string]string{"tier": "older", "env": "prod"}))
+			Expect(owners).Should(Equal(map[string]string{"tier": "older", "env": "newer"}))
+		})
+
+		It("Should ignore ClusterNamespaceLabels being deleted", func() {
+			deleted := newClusterNamespaceLabel("deleted", time.Hour, idandanielv1.NamespaceSelector{}, map[string]string{"tier": "deleted"})
+			deleted.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			clusterNamespaceLabels := &idandanielv1.ClusterNamespaceLabelList{Items: []idandanielv1.ClusterNamespaceLabel{deleted}}
+
+			labels, _ := clusterNamespaceLabels.GetLabelsFor(namespace)
+			Expect(labels).Should(BeEmpty())
+		})
+
+		It("Should take precedence over NamespaceLabels", func() {
+			namespaceLabels := &idandanielv1.NamespaceLabelList{Items: []idandanielv1.NamespaceLabel{{
+				ObjectMeta: metav1.ObjectMeta{Name: "local", Namespace: namespace.Name},
+				Spec:       idandanielv1.NamespaceLabelSpec{Labels: map[string]string{"tier": "local", "env": "prod", "app": "api"}},
+			}}}
+			labels, conflicts := namespaceLabels.ResolveLabels()
+
+			namespaceLabels.OverrideLabels(labels, conflicts, map[string]string{"tier": "cluster", "env": "prod"}, map[string]string{"tier": "global", "env": "global"}, "ClusterNamespaceLabel")
+
+			Expect(labels).Should(Equal(map[string]string{"tier": "cluster", "env": "prod", "app": "api"}))
+			Expect(conflicts).Should(Equal(map[string][]idandanielv1.LabelConflict{
+				"local": {{Key: "tier", Value: "local", Winner: "global", WinnerKind: "ClusterNamespaceLabel"}},
+			}))
+		})
+
+		It("Should select Namespaces again when they are created or their labels change", func() {
+			relabeled := namespace.DeepCopy()
+			relabeled.Labels["team"] = "search"
+
+			Expect(namespaceCreatedOrLabelsChanged().Create(event.CreateEvent{Object: namespace})).Should(BeTrue())
+			Expect(namespaceCreatedOrLabelsChanged().Update(event.UpdateEvent{ObjectOld: namespace, ObjectNew: relabeled})).Should(BeTrue())
+			Expect(namespaceCreatedOrLabelsChanged().Update(event.UpdateEvent{ObjectOld: namespace, ObjectNew: namespace.DeepCopy()})).Should(BeFalse())
+			Expect(namespaceCreatedOrLabelsChanged().Delete(event.DeleteEvent{Object: namespace})).Should(BeFalse())
+		})
+
+		It("Should queue the syncs of the Namespaces in the NamespaceLabel controller", func() {
+			namespaceLabels := &NamespaceLabelReconciler{namespaceEvents: make(chan event.GenericEvent, 2)}
+			reconciler := &ClusterNamespaceLabelReconciler{NamespaceLabels: namespaceLabels}
+
+			Expect(reconciler.syncNamespaces(context.Background(), []string{"tenant-a", "tenant-b"})).To(Succeed())
+			Expect(namespaceLabels.namespaceEvents).Should(Receive(HaveField("Object.ObjectMeta.Name", "tenant-a")))
+			Expect(namespaceLabels.namespaceEvents).Should(Receive(HaveField("Object.ObjectMeta.Name", "tenant-b")))
+
+			// Nothing reads the queue once the manager is stopped
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			namespaceLabels.namespaceEvents = make(chan event.GenericEvent)
+			Expect(reconciler.syncNamespaces(ctx, []string{"tenant-a"})).Should(MatchError(context.Canceled))
+		})
+
+		It("Should sync the Namespaces labeled only by ClusterNamespaceLabels", func() {
+			ctx := context.Background()
+			clusterNamespaceLabel := newClusterNamespaceLabel("cluster", time.Hour, idandanielv1.NamespaceSelector{Names: []string{"tenant-a"}}, map[string]string{"env": "prod"})
+			counting := &countingClient{Client: newFakeClient(
+				namespace.DeepCopy(),
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-b"}},
+				&clusterNamespaceLabel,
+			)}
+			reconciler := &NamespaceLabelReconciler{Client: counting, Scheme: counting.Scheme(), Recorder: &record.FakeRecorder{}, ResyncPeriod: time.Hour}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: namespace.Name}}
+
+			result, err := reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.RequeueAfter).Should(BeNumerically(">=", time.Hour))
+			synced := &corev1.Namespace{}
+			Expect(counting.Get(ctx, request.NamespacedName, synced)).To(Succeed())
+			Expect(synced.Labels).Should(HaveKeyWithValue("env", "prod"))
+			Expect(synced.Annotations).Should(HaveKeyWithValue(wrappers.OwnedLabelsAnnotation, "env"))
+
+			// The Namespace still owns the label once the ClusterNamespaceLabel is gone, it is synced to give it up
+			Expect(counting.Delete(ctx, &clusterNamespaceLabel)).To(Succeed())
+			counting.writes = 0
+			result, err = reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.RequeueAfter).Should(BeZero())
+			Expect(counting.writes).Should(Equal(1))
+
+			// A Namespace no NamespaceLabel or ClusterNamespaceLabel ever labeled is left alone
+			counting.writes = 0
+			result, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "tenant-b"}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.RequeueAfter).Should(BeZero())
+			Expect(counting.writes).Should(BeZero())
+		})
+	})
+
+	Context("With NamespaceLabelPolicies", func() {
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "tenant-a",
+				Labels: map[string]string{"tenant": "true"},
+			},
+		}
+
+		policy := idandanielv1.NamespaceLabelPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenants"},
+			Spec: idandanielv1.NamespaceLabelPolicySpec{
+				NamespaceSelector: idandanielv1.NamespaceSelector{Names: []string{"tenant-*"}},
+				Allow: []idandanielv1.LabelPolicyRule{
+					{Keys: []string{"tier", "team"}},
+					{Prefixes: []string{"pod-security.kubernetes.io"}},
+				},
+				Deny: []idandanielv1.LabelPolicyRule{
+					{Keys: []string{"tier"}, ValuePatterns: []string{"prod.*"}},
+					{Prefixes: []string{"pod-security.kubernetes.io"}, ValuePatterns: []string{"privileged"}},
+				},
+			},
+		}
+		policies := &idandanielv1.NamespaceLabelPolicyList{Items: []idandanielv1.NamespaceLabelPolicy{policy}}
+
+		It("Should deny labels in the deny list or missing from the allow list", func() {
+			Expect(policy.Denies("tier", "dev")).Should(BeEmpty())
+			Expect(policy.Denies("pod-security.kubernetes.io/enforce", "baseline")).Should(BeEmpty())
+			Expect(policy.Denies("tier", "production")).Should(Equal("the label is in the deny list"))
+			Expect(policy.Denies("pod-security.kubernetes.io/enforce", "privileged")).Should(Equal("the label is in the deny list"))
+			Expect(policy.Denies("cost-center", "cc_1")).Should(Equal("the label is not in the allow list"))
+		})
+
+		It("Should deny every label when a value pattern is invalid", func() {
+			broken := policy.DeepCopy()
+			broken.Spec.Deny = []idandanielv1.LabelPolicyRule{{ValuePatterns: []string{"("}}}
+
+			Expect(broken.Denies("tier", "dev")).Should(ContainSubstring("invalid value pattern"))
+		})
+
+		It("Should only apply the policies selecting the Namespace", func() {
+			other := namespace.DeepCopy()
+			other.Name = "other"
+
+			Expect(policies.Check(namespace, "tier", "prod")).Should(Equal("Denied by NamespaceLabelPolicy tenants: the label is in the deny list"))
+			Expect(policies.Check(other, "tier", "prod")).Should(BeEmpty())
+		})
+
+		It("Should report denied labels as skipped", func() {
+			namespaceLabel := idandanielv1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: "denied", Namespace: namespace.Name},
+				Spec:       idandanielv1.NamespaceLabelSpec{Labels: map[string]string{"tier": "prod", "team": "a"}},
+			}
+			namespaceLabels := &idandanielv1.NamespaceLabelList{Items: []idandanielv1.NamespaceLabel{namespaceLabel}}
+
+			denied := policies.DeniedLabels(namespace, namespaceLabels)
+			Expect(denied).Should(Equal(map[string]map[string]string{
+				"denied": {"tier": "Denied by NamespaceLabelPolicy tenants: the label is in the deny list"},
+			}))
+			Expect(namespaceLabels.WithoutLabels(denied).GetLabels()).Should(Equal(map[string]string{"team": "a"}))
+			Expect(namespaceLabels.GetLabels()).Should(HaveKey("tier"))
+
+			setStatus(&namespaceLabel, &syncResult{
+				namespaceLabels: map[string]string{"team": "a"},
+				deniedLabels:    denied,
+			})
+			Expect(namespaceLabel.Status.AppliedLabels).Should(Equal(map[string]string{"team": "a"}))
+			Expect(namespaceLabel.Status.SkippedLabels).Should(ConsistOf(idandanielv1.SkippedLabel{
+				Key:     "tier",
+				Value:   "prod",
+				Reason:  idandanielv1.ReasonPolicyDenied,
+				Message: "Denied by NamespaceLabelPolicy tenants: the label is in the deny list",
+			}))
+		})
+	})
+
+	Context("With Namespace events", func() {
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   NamespaceLabelName,
+				Labels: map[string]string{"team": "a"},
+			},
+		}
+
+		It("Should enforce the labels again when they change", func() {
+			drifted := namespace.DeepCopy()
+			delete(drifted.Labels, "team")
+
+			Expect(namespaceMetadataChanged().Update(event.UpdateEvent{ObjectOld: namespace, ObjectNew: drifted})).Should(BeTrue())
+		})
+
+		It("Should enforce the annotations again when they change", func() {
+			drifted := namespace.DeepCopy()
+			drifted.Annotations = map[string]string{"team": "b"}
+
+			Expect(namespaceMetadataChanged().Update(event.UpdateEvent{ObjectOld: namespace, ObjectNew: drifted})).Should(BeTrue())
+		})
+
+		It("Should ignore changes which are not label or annotation changes", func() {
+			updated := namespace.DeepCopy()
+			updated.Status.Phase = corev1.NamespaceTerminating
+
+			Expect(namespaceMetadataChanged().Update(event.UpdateEvent{ObjectOld: namespace, ObjectNew: updated})).Should(BeFalse())
+			Expect(namespaceMetadataChanged().Create(event.CreateEvent{Object: namespace})).Should(BeFalse())
+			Expect(namespaceMetadataChanged().Delete(event.DeleteEvent{Object: namespace})).Should(BeFalse())
+		})
+	})
+
+	Context("With audit mode", func() {
+		const AuditedNamespace = "audited"
+
+		ctx := context.Background()
+		var recorder *record.FakeRecorder
+
+		newReconciler := func(dryRun bool, objects ...client.Object) *NamespaceLabelReconciler {
+			reconciler := newTestReconciler(objects...)
+			reconciler.Client = &countingClient{Client: reconciler.Client}
+			reconciler.DryRun = dryRun
+			recorder = reconciler.Recorder.(*record.FakeRecorder)
+			return reconciler
+		}
+		newNamespace := func() *corev1.Namespace {
+			return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        AuditedNamespace,
+				Labels:      map[string]string{"team": "a", "old": "value"},
+				Annotations: map[string]string{wrappers.OwnedLabelsAnnotation: "old"},
+			}}
+		}
+		newNamespaceLabel := func(mode idandanielv1.Mode) *idandanielv1.NamespaceLabel {
+			return &idandanielv1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: "audit", Namespace: AuditedNamespace},
+				Spec: idandanielv1.NamespaceLabelSpec{
+					Labels: map[string]string{"tier": "gold"},
+					Mode:   mode,
+				},
+			}
+		}
+
+		It("Should split the NamespaceLabels by mode, freezing the audited ones", func() {
+			namespace := newNamespace()
+			namespace.Labels["tier"] = "silver"
+			namespace.Annotations[wrappers.OwnedLabelsAnnotation] = "old,tier"
+			namespaceLabels := &idandanielv1.NamespaceLabelList{Items: []idandanielv1.NamespaceLabel{
+				*newNamespaceLabel(idandanielv1.Enforce), *newNamespaceLabel(idandanielv1.Audit), *newNamespaceLabel(""),
+			}}
+
+			enforced, audited := (&NamespaceLabelReconciler{}).splitByMode(namespace, namespaceLabels)
+			Expect(enforced.Items).Should(HaveLen(3))
+			Expect(enforced.Items[0].Spec.Labels).Should(Equal(map[string]string{"tier": "gold"}))
+			Expect(enforced.Items[1].Spec.Labels).Should(Equal(map[string]string{"tier": "silver"}))
+			Expect(audited.Items).Should(HaveLen(1))
+			Expect(audited.Items[0].Spec.Labels).Should(Equal(map[string]string{"tier": "gold"}))
+			Expect(withoutAudited(enforced).Items).Should(HaveLen(2))
+
+			enforced, audited = (&NamespaceLabelReconciler{DryRun: true}).splitByMode(namespace, namespaceLabels)
+			Expect(enforced.Items).Should(BeEmpty())
+			Expect(audited.Items).Should(HaveLen(3))
+		})
+
+		It("Should keep the labels an enforced NamespaceLabel applied when it is switched to audit", func() {
+			namespace := newNamespace()
+			namespace.Labels["tier"] = "silver"
+			namespace.Annotations[wrappers.OwnedLabelsAnnotation] = "tier"
+			reconciler := newReconciler(false, namespace, newNamespaceLabel(idandanielv1.Audit))
+
+			Expect(reconciler.sync(ctx, AuditedNamespace)).Should(Succeed())
+
+			current := &corev1.Namespace{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: AuditedNamespace}, current)).Should(Succeed())
+			Expect(current.Labels).Should(HaveKeyWithValue("tier", "silver"))
+			Expect(current.Annotations).Should(HaveKeyWithValue(wrappers.OwnedLabelsAnnotation, "tier"))
+
+			namespaceLabel := &idandanielv1.NamespaceLabel{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "audit", Namespace: AuditedNamespace}, namespaceLabel)).Should(Succeed())
+			Expect(namespaceLabel.Status.PendingChanges).Should(Equal(&idandanielv1.PendingChanges{
+				AddedLabels: map[string]string{"tier": "gold"},
+			}))
+		})
+
+		It("Should report the pending changes without changing the Namespace in dry-run", func() {
+			reconciler := newReconciler(true, newNamespace(), newNamespaceLabel(idandanielv1.Enforce))
+
+			Expect(reconciler.sync(ctx, AuditedNamespace)).Should(Succeed())
+
+			namespace := &corev1.Namespace{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: AuditedNamespace}, namespace)).Should(Succeed())
+			Expect(namespace.Labels).Should(Equal(newNamespace().Labels))
+			Expect(namespace.Annotations).Should(Equal(newNamespace().Annotations))
+
+			namespaceLabel := &idandanielv1.NamespaceLabel{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "audit", Namespace: AuditedNamespace}, namespaceLabel)).Should(Succeed())
+			Expect(namespaceLabel.Status.PendingChanges).Should(Equal(&idandanielv1.PendingChanges{
+				AddedLabels:   map[string]string{"tier": "gold"},
+				RemovedLabels: []string{"old"},
+			}))
+			Expect(namespaceLabel.Status.AppliedLabels).Should(Equal(map[string]string{"tier": "gold"}))
+			ready := meta.FindStatusCondition(namespaceLabel.Status.Conditions, idandanielv1.ConditionReady)
+			Expect(ready).ShouldNot(BeNil())
+			Expect(ready.Status).Should(Equal(metav1.ConditionFalse))
+			Expect(ready.Reason).Should(Equal(idandanielv1.ReasonAudit))
+
+			Expect(receivedEvents(recorder)).Should(Equal([]string{
+				"Normal Audit Would add labels to Namespace audited: tier=gold",
+				"Normal Audit Would remove labels from Namespace audited: old",
+				"Normal Audit Would add labels to Namespace audited: tier=gold",
+				"Normal Audit Would remove labels from Namespace audited: old",
+			}))
+		})
+
+		It("Should report the labels of the ClusterNamespaceLabels in dry-run without NamespaceLabels", func() {
+			clusterNamespaceLabel := &idandanielv1.ClusterNamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec: idandanielv1.ClusterNamespaceLabelSpec{
+					NamespaceSelector: idandanielv1.NamespaceSelector{Names: []string{AuditedNamespace}},
+					Labels:            map[string]string{"env": "prod"},
+				},
+			}
+			reconciler := newReconciler(true, newNamespace(), clusterNamespaceLabel)
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: AuditedNamespace}})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			namespace := &corev1.Namespace{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: AuditedNamespace}, namespace)).Should(Succeed())
+			Expect(namespace.Labels).Should(Equal(newNamespace().Labels))
+			Expect(reconciler.Client.(*countingClient).writes).Should(BeZero())
+			Expect(receivedEvents(recorder)).Should(Equal([]string{
+				"Normal Audit Would add labels to Namespace audited: env=prod",
+				"Normal Audit Would remove labels from Namespace audited: old",
+			}))
+		})
+
+		It("Should not report the same pending changes twice", func() {
+			reconciler := newReconciler(true, newNamespace(), newNamespaceLabel(idandanielv1.Audit))
+
+			Expect(reconciler.sync(ctx, AuditedNamespace)).Should(Succeed())
+			Expect(receivedEvents(recorder)).Should(HaveLen(4))
+
+			Expect(reconciler.sync(ctx, AuditedNamespace)).Should(Succeed())
+			Expect(receivedEvents(recorder)).Should(BeEmpty())
+		})
+
+		It("Should leave the Namespace unchanged when an audited NamespaceLabel is deleted", func() {
+			namespace := newNamespace()
+			namespace.Labels["tier"] = "gold"
+			namespace.Annotations[wrappers.OwnedLabelsAnnotation] = "old,tier"
+			namespaceLabel := newNamespaceLabel(idandanielv1.Audit)
+			reconciler := newReconciler(false, namespace, namespaceLabel)
+
+			Expect(reconciler.removeLabelsFromAssociatedNamespace(ctx, namespaceLabel)).Should(Succeed())
+
+			current := &corev1.Namespace{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: AuditedNamespace}, current)).Should(Succeed())
+			Expect(current.Labels).Should(Equal(namespace.Labels))
+			Expect(current.Annotations).Should(Equal(namespace.Annotations))
+			Expect(receivedEvents(recorder)).Should(Equal([]string{
+				"Normal Audit Would remove labels from Namespace audited: tier",
+				"Normal Audit Would remove labels from Namespace audited: tier",
+			}))
+		})
+
+		It("Should only report the labels the deletion of a NamespaceLabel would remove in dry-run", func() {
+			namespace := newNamespace()
+			namespace.Labels["tier"] = "gold"
+			namespace.Annotations[wrappers.OwnedLabelsAnnotation] = "old,tier"
+			namespaceLabel := newNamespaceLabel(idandanielv1.Enforce)
+			reconciler := newReconciler(true, namespace, namespaceLabel)
+
+			Expect(reconciler.removeLabelsFromAssociatedNamespace(ctx, namespaceLabel)).Should(Succeed())
+
+			current := &corev1.Namespace{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: AuditedNamespace}, current)).Should(Succeed())
+			Expect(current.Labels).Should(HaveKeyWithValue("tier", "gold"))
+			Expect(receivedEvents(recorder)).Should(Equal([]string{
+				"Normal Audit Would remove labels from Namespace audited: tier",
+				"Normal Audit Would remove labels from Namespace audited: tier",
+			}))
+		})
+	})
+
+	Context("With paused reconciliation", func() {
+		const PausedNamespace = "paused"
+
+		ctx := context.Background()
+		var recorder *record.FakeRecorder
+
+		newReconciler := func(objects ...client.Object) *NamespaceLabelReconciler {
+			reconciler := newTestReconciler(objects...)
+			recorder = reconciler.Recorder.(*record.FakeRecorder)
+			return reconciler
+		}
+		newNamespace := func() *corev1.Namespace {
+			return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        PausedNamespace,
+				Labels:      map[string]string{"team": "a", "tier": "silver"},
+				Annotations: map[string]string{wrappers.OwnedLabelsAnnotation: "tier"},
+			}}
+		}
+		newNamespaceLabel := func(paused bool) *idandanielv1.NamespaceLabel {
+			namespaceLabel := &idandanielv1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: "pause", Namespace: PausedNamespace},
+				Spec: idandanielv1.NamespaceLabelSpec{
+					Labels: map[string]string{"tier": "gold", "env": "prod"},
+				},
+			}
+			if paused {
+				namespaceLabel.Annotations = map[string]string{idandanielv1.PausedAnnotation: "true"}
+			}
+			return namespaceLabel
+		}
+		pausedCondition := func(reconciler *NamespaceLabelReconciler) *metav1.Condition {
+			namespaceLabel := &idandanielv1.NamespaceLabel{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "pause", Namespace: PausedNamespace}, namespaceLabel)).Should(Succeed())
+			return meta.FindStatusCondition(namespaceLabel.Status.Conditions, idandanielv1.ConditionPaused)
+		}
+
+		It("Should only recognize the paused annotation set to true", func() {
+			Expect(idandanielv1.IsPaused(newNamespaceLabel(true))).Should(BeTrue())
+			Expect(idandanielv1.IsPaused(newNamespaceLabel(false))).Should(BeFalse())
+
+			namespace := newNamespace()
+			namespace.Annotations[idandanielv1.PausedAnnotation] = "false"
+			Expect(idandanielv1.IsPaused(namespace)).Should(BeFalse())
+		})
+
+		It("Should keep the owned values of the paused NamespaceLabels", func() {
+			namespaceLabels := &idandanielv1.NamespaceLabelList{Items: []idandanielv1.NamespaceLabel{*newNamespaceLabel(true)}}
+
+			frozen := (&NamespaceLabelReconciler{}).freezePaused(newNamespace(), namespaceLabels)
+			Expect(frozen.Items[0].Spec.Labels).Should(Equal(map[string]string{"tier": "silver"}))
+			Expect(namespaceLabels.Items[0].Spec.Labels).Should(Equal(newNamespaceLabel(true).Spec.Labels))
+		})
+
+		It("Should not sync a paused Namespace", func() {
+			namespace := newNamespace()
+			namespace.Annotations[idandanielv1.PausedAnnotation] = "true"
+			reconciler := newReconciler(namespace, newNamespaceLabel(false))
+
+			Expect(reconciler.sync(ctx, PausedNamespace)).Should(Succeed())
+
+			current := &corev1.Namespace{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: PausedNamespace}, current)).Should(Succeed())
+			Expect(current.Labels).Should(Equal(newNamespace().Labels))
+
+			paused := pausedCondition(reconciler)
+			Expect(paused).ShouldNot(BeNil())
+			Expect(paused.Status).Should(Equal(metav1.ConditionTrue))
+			Expect(paused.Message).Should(ContainSubstring("of the Namespace"))
+		})
+
+		It("Should only add the finalizer of a paused NamespaceLabel", func() {
+			reconciler := newReconciler(newNamespace(), newNamespaceLabel(true))
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: PausedNamespace}})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			current := &corev1.Namespace{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: PausedNamespace}, current)).Should(Succeed())
+			Expect(current.Labels).Should(Equal(newNamespace().Labels))
+
+			namespaceLabel := &idandanielv1.NamespaceLabel{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "pause", Namespace: PausedNamespace}, namespaceLabel)).Should(Succeed())
+			Expect(namespaceLabel.Finalizers).Should(ContainElement(finalizer))
+			Expect(meta.FindStatusCondition(namespaceLabel.Status.Conditions, idandanielv1.ConditionReady)).Should(BeNil())
+			paused := pausedCondition(reconciler)
+			Expect(paused).ShouldNot(BeNil())
+			Expect(paused.Status).Should(Equal(metav1.ConditionTrue))
+			Expect(paused.Reason).Should(Equal(idandanielv1.ReasonPaused))
+		})
+
+		It("Should leave the labels of a paused NamespaceLabel being deleted", func() {
+			deleted := newNamespaceLabel(true)
+			deleted.Finalizers = []string{finalizer}
+			deleted.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			namespace := newNamespace()
+			namespace.Labels["team"] = "payments"
+			namespace.Annotations[wrappers.OwnedLabelsAnnotation] = "team,tier"
+			namespace.Annotations[wrappers.PreviousLabelsAnnotation] = `{"tier":"bronze"}`
+			namespace.ManagedFields = []metav1.ManagedFieldsEntry{appliedEntry(FieldManager, namespace)}
+			pruning := &pruningClient{countingClient: countingClient{Client: newFakeClient(namespace, deleted, &idandanielv1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: PausedNamespace, Finalizers: []string{finalizer}},
+				Spec:       idandanielv1.NamespaceLabelSpec{Labels: map[string]string{"team": "payments"}},
+			})}}
+			recorder = record.NewFakeRecorder(20)
+			reconciler := &NamespaceLabelReconciler{Client: pruning, Scheme: pruning.Scheme(), Recorder: recorder}
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: PausedNamespace}})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			// The labels are no longer owned, so neither the sync of the other NamespaceLabel nor the apply removed them
+			current := &corev1.Namespace{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: PausedNamespace}, current)).Should(Succeed())
+			Expect(current.Labels).Should(Equal(map[string]string{"team": "payments", "tier": "silver"}))
+			Expect(current.Annotations).Should(HaveKeyWithValue(wrappers.OwnedLabelsAnnotation, "team"))
+			Expect(current.Annotations).ShouldNot(HaveKey(wrappers.PreviousLabelsAnnotation))
+			Expect(receivedEvents(recorder)).Should(ContainElement(ContainSubstring("Reconciliation is paused")))
+		})
+	})
+
+	Context("With label templates", func() {
+		const TemplatedNamespace = "team-templated"
+
+		ctx := context.Background()
+
+		It("Should apply the rendered labels and report the failed templates in the status", func() {
+			reconciler := newTestReconciler()
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:        TemplatedNamespace,
+				Annotations: map[string]string{"owner": "alice"},
+			}}
+			namespaceLabels := &idandanielv1.NamespaceLabelList{Items: []idandanielv1.NamespaceLabel{{
+				ObjectMeta: metav1.ObjectMeta{Name: "templated", Namespace: TemplatedNamespace},
+				Spec: idandanielv1.NamespaceLabelSpec{
+					Templated: true,
+					Labels: map[string]string{
+						"owner": "{{ .Namespace.Annotations.owner }}",
+						"env":   `{{ trimPrefix "team-" .Namespace.Name }}`,
+						"group": "{{ .Namespace.Annotations.group }}",
+					},
+				},
+			}}}
+
+			rendered, invalid, unavailableSources, err := reconciler.resolveLabels(ctx, namespace, namespaceLabels)
+			Expect(err).ShouldNot(HaveOccurred())
+			desired, result, err := reconciler.desiredNamespace(ctx, namespace, rendered, invalid, unavailableSources)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(desired.Labels).Should(Equal(map[string]string{"owner": "alice", "env": "templated"}))
+
+			namespaceLabel := &rendered.Items[0]
+			setStatus(namespaceLabel, result)
+			Expect(namespaceLabel.Status.AppliedLabels).Should(Equal(map[string]string{"owner": "alice", "env": "templated"}))
+			Expect(namespaceLabel.Status.SkippedLabels).Should(HaveLen(1))
+			Expect(namespaceLabel.Status.SkippedLabels[0].Key).Should(Equal("group"))
+			Expect(namespaceLabel.Status.SkippedLabels[0].Reason).Should(Equal(idandanielv1.ReasonTemplateFailed))
+			Expect(namespaceLabel.Status.SkippedLabels[0].Message).Should(ContainSubstring("Failed to render the label template"))
+		})
+	})
+
+	Context("With apply policies", func() {
+		const PoliciesNamespace = "policies"
+
+		ctx := context.Background()
+
+		newNamespace := func() *corev1.Namespace {
+			return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name: PoliciesNamespace,
+				Labels: map[string]string{
+					"kubernetes.io/metadata.name": PoliciesNamespace,
+					"team":                        "platform",
+					"legacy":                      "true",
+					"tier":                        "silver",
+				},
+				Annotations: map[string]string{wrappers.OwnedLabelsAnnotation: "tier"},
+			}}
+		}
+
+		It("Should keep the existing values of ApplyIfAbsent labels and remove the RemoveOnly labels", func() {
+			wrappedNamespace := &wrappers.NamespaceWrapper{Namespace: newNamespace()}
+			labelsToAdd := map[string]string{
+				"team":   "payments",
+				"tier":   "gold",
+				"region": "eu",
+			}
+			wrappedNamespace.UpdateLabelsWithPolicies(labelsToAdd, map[string]idandanielv1.ApplyPolicy{
+				"team":   idandanielv1.ApplyIfAbsent,
+				"tier":   idandanielv1.ApplyIfAbsent,
+				"region": idandanielv1.ApplyIfAbsent,
+			})
+			wrappedNamespace.RemoveMatchingLabels(func(key string) bool {
+				return key == "legacy" || key == "kubernetes.io/metadata.name"
+			}, labelsToAdd)
+
+			Expect(wrappedNamespace.Labels).Should(Equal(map[string]string{
+				"kubernetes.io/metadata.name": PoliciesNamespace,
+				"team":                        "platform",
+				"tier":                        "silver",
+				"region":                      "eu",
+			}))
+			Expect(wrappedNamespace.OwnedLabels()).Should(Equal([]string{"region", "tier"}))
+			Expect(wrappedNamespace.RemovedLabels()).Should(Equal([]string{"legacy"}))
+		})
+
+		It("Should report the policy every label was applied with", func() {
+			reconciler := newTestReconciler()
+			namespace := newNamespace()
+			namespaceLabels := &idandanielv1.NamespaceLabelList{Items: []idandanielv1.NamespaceLabel{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "defaults", Namespace: PoliciesNamespace, CreationTimestamp: metav1.NewTime(time.Unix(0, 0))},
+					Spec: idandanielv1.NamespaceLabelSpec{
+						Labels:        map[string]string{"team": "payments", "tier": "gold", "legacy": ""},
+						ApplyPolicies: map[string]idandanielv1.ApplyPolicy{"team": idandanielv1.ApplyIfAbsent, "tier": idandanielv1.ApplyIfAbsent, "legacy": idandanielv1.RemoveOnly},
+					},
+				},
+			}}
+
+			desired, result, err := reconciler.desiredNamespace(ctx, namespace, namespaceLabels, nil, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(desired.Labels).ShouldNot(HaveKey("legacy"))
+			Expect(desired.RemovedLabels()).Should(Equal([]string{"legacy"}))
+
+			defaults := &namespaceLabels.Items[0]
+			setStatus(defaults, result)
+			Expect(defaults.Status.ApplyPolicies).Should(Equal(map[string]idandanielv1.ApplyPolicy{
+				"team":   idandanielv1.ApplyIfAbsent,
+				"tier":   idandanielv1.ApplyIfAbsent,
+				"legacy": idandanielv1.RemoveOnly,
+			}))
+			Expect(defaults.Status.AppliedLabels).Should(Equal(map[string]string{"tier": "silver", "team": "platform"}))
+			Expect(defaults.Status.SkippedLabels).Should(BeEmpty())
+		})
+
+		It("Should not let RemoveOnly labels compete over a key or be denied by the NamespaceLabelPolicies", func() {
+			policy := &idandanielv1.NamespaceLabelPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "no-legacy"},
+				Spec:       idandanielv1.NamespaceLabelPolicySpec{Deny: []idandanielv1.LabelPolicyRule{{Keys: []string{"legacy"}, ValuePatterns: []string{""}}}},
+			}
+			reconciler := newTestReconciler(policy)
+			namespaceLabels := &idandanielv1.NamespaceLabelList{Items: []idandanielv1.NamespaceLabel{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "removing", Namespace: PoliciesNamespace, CreationTimestamp: metav1.NewTime(time.Unix(0, 0))},
+					Spec: idandanielv1.NamespaceLabelSpec{
+						Labels:         map[string]string{"legacy": ""},
+						ApplyPolicies:  map[string]idandanielv1.ApplyPolicy{"legacy": idandanielv1.RemoveOnly},
+						ConflictPolicy: idandanielv1.Refuse,
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "enforced", Namespace: PoliciesNamespace, CreationTimestamp: metav1.NewTime(time.Unix(60, 0))},
+					Spec:       idandanielv1.NamespaceLabelSpec{Labels: map[string]string{"legacy": "false"}},
+				},
+			}}
+
+			desired, result, err := reconciler.desiredNamespace(ctx, newNamespace(), namespaceLabels, nil, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(desired.Labels).Should(HaveKeyWithValue("legacy", "false"))
+			Expect(desired.RemovedLabels()).Should(BeEmpty())
+			Expect(result.conflicts).Should(BeEmpty())
+			Expect(result.deniedLabels).Should(BeEmpty())
+
+			removing := &namespaceLabels.Items[0]
+			setStatus(removing, result)
+			Expect(removing.Status.ApplyPolicies).Should(Equal(map[string]idandanielv1.ApplyPolicy{"legacy": idandanielv1.RemoveOnly}))
+			Expect(removing.Status.SkippedLabels).Should(ConsistOf(idandanielv1.SkippedLabel{
+				Key:     "legacy",
+				Reason:  idandanielv1.ReasonLabelConflict,
+				Message: "The label is still set on the Namespace",
+			}))
+
+			enforced := &namespaceLabels.Items[1]
+			setStatus(enforced, result)
+			Expect(enforced.Status.ApplyPolicies).Should(Equal(map[string]idandanielv1.ApplyPolicy{"legacy": idandanielv1.ApplyEnforce}))
+			Expect(enforced.Status.AppliedLabels).Should(Equal(map[string]string{"legacy": "false"}))
+			Expect(enforced.Status.SkippedLabels).Should(BeEmpty())
+		})
+
+		It("Should remove the labels set by others and restore the previous values with a merge patch", func() {
+			namespace := newNamespace()
+			k8sClient := newFakeClient(namespace)
+
+			patch, err := metadataPatch([]string{"legacy"}, map[string]string{"team": "core"}, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(k8sClient.Patch(ctx, namespace, patch)).To(Succeed())
+
+			patched := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: PoliciesNamespace}, patched)).To(Succeed())
+			Expect(patched.Labels).Should(Equal(map[string]string{"kubernetes.io/metadata.name": PoliciesNamespace, "team": "core", "tier": "silver"}))
+		})
+	})
+
+	Context("With label removals", func() {
+		const RemovalsNamespace = "removals"
+
+		ctx := context.Background()
+
+		It("Should remove the matching labels set by others and record the removals", func() {
+			reconciler := newTestReconciler()
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name: RemovalsNamespace,
+				Labels: map[string]string{
+					"kubernetes.io/metadata.name": RemovalsNamespace,
+					"team-old":                    "core",
+					"team-older":                  "platform",
+					"team-new":                    "payments",
+					"legacy.example.com/owner":    "alice",
+					"tier":                        "gold",
+				},
+			}}
+			namespaceLabels := &idandanielv1.NamespaceLabelList{Items: []idandanielv1.NamespaceLabel{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "cleanup", Namespace: RemovalsNamespace},
+					Spec: idandanielv1.NamespaceLabelSpec{
+						RemoveLabels: []string{"team-old*", "legacy.example.com/*", "kubernetes.io/*"},
+					},
+					Status: idandanielv1.NamespaceLabelStatus{RemovedLabels: []idandanielv1.RemovedLabel{
+						{Key: "legacy.example.com/team", Value: "core"},
+						{Key: "renamed", Value: "true"},
+					}},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: RemovalsNamespace},
+					Spec:       idandanielv1.NamespaceLabelSpec{Labels: map[string]string{"team-new": "payments", "legacy.example.com/owner": "bob"}},
+				},
+			}}
+
+			desired, result, err := reconciler.desiredNamespace(ctx, namespace, namespaceLabels, nil, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(desired.Labels).Should(Equal(map[string]string{
+				"kubernetes.io/metadata.name": RemovalsNamespace,
+				"team-new":                    "payments",
+				"legacy.example.com/owner":    "bob",
+				"tier":                        "gold",
+			}))
+			Expect(desired.RemovedLabels()).Should(Equal([]string{"team-old", "team-older"}))
+
+			cleanup := &namespaceLabels.Items[0]
+			setStatus(cleanup, result)
+			Expect(cleanup.Status.RemovedLabels).Should(HaveLen(3))
+			Expect(cleanup.Status.RemovedLabels[0]).Should(Equal(idandanielv1.RemovedLabel{Key: "legacy.example.com/team", Value: "core"}))
+			Expect(cleanup.Status.RemovedLabels[1].Key).Should(Equal("team-old"))
+			Expect(cleanup.Status.RemovedLabels[1].Value).Should(Equal("core"))
+			Expect(cleanup.Status.RemovedLabels[1].RemovedAt.IsZero()).Should(BeFalse())
+			Expect(cleanup.Status.RemovedLabels[2].Key).Should(Equal("team-older"))
+
+			team := &namespaceLabels.Items[1]
+			setStatus(team, result)
+			Expect(team.Status.RemovedLabels).Should(BeEmpty())
+		})
+
+		It("Should not record the removals computed in audit mode", func() {
+			namespaceLabel := &idandanielv1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: "cleanup", Namespace: RemovalsNamespace},
+				Spec:       idandanielv1.NamespaceLabelSpec{RemoveLabels: []string{"team-old"}},
+			}
+
+			setStatus(namespaceLabel, &syncResult{removedLabels: map[string]string{"team-old": "core"}, audit: true})
+			Expect(namespaceLabel.Status.RemovedLabels).Should(BeEmpty())
+		})
+	})
+
+	Context("With periodic resyncs", func() {
+		const ResyncNamespace = "resynced"
+
+		ctx := context.Background()
+
+		newNamespaceLabel := func(interval *metav1.Duration) *idandanielv1.NamespaceLabel {
+			return &idandanielv1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: "resync", Namespace: ResyncNamespace},
+				Spec: idandanielv1.NamespaceLabelSpec{
+					Labels:         map[string]string{"team": "payments"},
+					ResyncInterval: interval,
+				},
+			}
+		}
+
+		It("Should resync after the manager period with jitter", func() {
+			reconciler := &NamespaceLabelReconciler{ResyncPeriod: 10 * time.Minute}
+			for i := 0; i < 20; i++ {
+				Expect(reconciler.resyncAfter(newNamespaceLabel(nil))).Should(And(
+					BeNumerically(">=", 10*time.Minute),
+					BeNumerically("<=", 11*time.Minute),
+				))
+			}
+		})
+
+		It("Should resync after the interval of the NamespaceLabel instead of the manager period", func() {
+			reconciler := &NamespaceLabelReconciler{ResyncPeriod: 10 * time.Minute}
+			Expect(reconciler.resyncAfter(newNamespaceLabel(&metav1.Duration{Duration: time.Hour}))).Should(And(
+				BeNumerically(">=", time.Hour),
+				BeNumerically("<=", 66*time.Minute),
+			))
+
+			reconciler = &NamespaceLabelReconciler{}
+			Expect(reconciler.resyncAfter(newNamespaceLabel(&metav1.Duration{Duration: 2 * time.Minute}))).Should(BeNumerically(">=", 2*time.Minute))
+			Expect(reconciler.resyncAfter(newNamespaceLabel(nil))).Should(BeZero())
+		})
+
+		It("Should requeue the synced NamespaceLabels", func() {
+			reconciler := newTestReconciler(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ResyncNamespace}},
+				newNamespaceLabel(nil),
+			)
+			reconciler.DryRun = true
+			reconciler.ResyncPeriod = 10 * time.Minute
+
+			result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: ResyncNamespace}})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.RequeueAfter).Should(BeNumerically(">=", 10*time.Minute))
+
+			namespaceLabel := &idandanielv1.NamespaceLabel{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "resync", Namespace: ResyncNamespace}, namespaceLabel)).To(Succeed())
+			Expect(namespaceLabel.Status.LastSyncTime).ShouldNot(BeNil())
+		})
+
+		It("Should update the last sync time at most once a minute", func() {
+			namespaceLabel := newNamespaceLabel(nil)
+			setStatus(namespaceLabel, &syncResult{namespaceLabels: map[string]string{"team": "payments"}})
+			Expect(namespaceLabel.Status.LastSyncTime).ShouldNot(BeNil())
+
+			recent := metav1.NewTime(time.Now().Add(-10 * time.Second))
+			namespaceLabel.Status.LastSyncTime = recent.DeepCopy()
+			setStatus(namespaceLabel, &syncResult{namespaceLabels: map[string]string{"team": "payments"}})
+			Expect(namespaceLabel.Status.LastSyncTime.Time).Should(Equal(recent.Time))
+
+			old := metav1.NewTime(time.Now().Add(-2 * time.Minute))
+			namespaceLabel.Status.LastSyncTime = old.DeepCopy()
+			setStatus(namespaceLabel, &syncResult{namespaceLabels: map[string]string{"team": "payments"}})
+			Expect(namespaceLabel.Status.LastSyncTime.Time).Should(BeTemporally(">", old.Time))
+		})
+
+		It("Should not update the last sync time of failed syncs", func() {
+			namespaceLabel := newNamespaceLabel(nil)
+			setStatus(namespaceLabel, &syncResult{err: errors.New("unavailable")})
+			Expect(namespaceLabel.Status.LastSyncTime).Should(BeNil())
+		})
+	})
+
+	Context("With reconciles keyed by Namespace", func() {
+		const KeyedNamespace = "keyed"
+
+		ctx := context.Background()
+
+		newNamespace := func(managedFields ...metav1.ManagedFieldsEntry) *corev1.Namespace {
+			return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:          KeyedNamespace,
+				Labels:        map[string]string{"team": "payments", "manual": "true"},
+				Annotations:   map[string]string{wrappers.OwnedLabelsAnnotation: "team"},
+				ManagedFields: managedFields,
+			}}
+		}
+		newNamespaceLabel := func(name string, namespace string) *idandanielv1.NamespaceLabel {
+			return &idandanielv1.NamespaceLabel{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Finalizers: []string{finalizer}},
+				Spec:       idandanielv1.NamespaceLabelSpec{Labels: map[string]string{"team": "payments"}},
+			}
+		}
+		applied := appliedEntry(FieldManager, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Labels:      map[string]string{"team": "payments"},
+			Annotations: map[string]string{wrappers.OwnedLabelsAnnotation: "team"},
+		}})
+
+		It("Should map the NamespaceLabels to their Namespaces once", func() {
+			Expect(namespaceOf(newNamespaceLabel("team", KeyedNamespace))).Should(Equal([]reconcile.Request{
+				{NamespacedName: types.NamespacedName{Name: KeyedNamespace}},
+			}))
+			Expect(namespaceRequests(&idandanielv1.NamespaceLabelList{Items: []idandanielv1.NamespaceLabel{
+				*newNamespaceLabel("team", KeyedNamespace),
+				*newNamespaceLabel("tier", KeyedNamespace),
+				*newNamespaceLabel("team", "other"),
+			}})).Should(Equal([]reconcile.Request{
+				{NamespacedName: types.NamespacedName{Name: KeyedNamespace}},
+				{NamespacedName: types.NamespacedName{Name: "other"}},
+			}))
+		})
+
+		It("Should only skip the write of a Namespace already applied by the controller", func() {
+			desired := (&NamespaceLabelReconciler{}).wrapNamespace(newNamespace().DeepCopy())
+			desired.UpdateLabels(true, map[string]string{"team": "payments"})
+			Expect(desired.HasChanges(newNamespace(applied), FieldManager)).Should(BeFalse())
+			Expect(desired.HasChanges(newNamespace(), FieldManager)).Should(BeTrue())
+
+			desired.UpdateLabels(true, map[string]string{"team": "checkout"})
+			Expect(desired.HasChanges(newNamespace(applied), FieldManager)).Should(BeTrue())
+		})
+
+		It("Should write a Namespace whose owned keys another field manager took over", func() {
+			desired := (&NamespaceLabelReconciler{}).wrapNamespace(newNamespace().DeepCopy())
+			desired.UpdateLabels(true, map[string]string{"team": "payments"})
+			takenOver := appliedEntry(FieldManager, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{wrappers.OwnedLabelsAnnotation: "team"},
+			}})
+			Expect(desired.HasChanges(newNamespace(takenOver), FieldManager)).Should(BeTrue())
+
+			// A key the field manager still applies but no longer owns has to be given up as well
+			extra := appliedEntry(FieldManager, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Labels:      map[string]string{"team": "payments", "manual": "true"},
+				Annotations: map[string]string{wrappers.OwnedLabelsAnnotation: "team"},
+			}})
+			Expect(desired.HasChanges(newNamespace(extra), FieldManager)).Should(BeTrue())
+		})
+
+		It("Should sync the Namespace once for all its NamespaceLabels without writing it when up to date", func() {
+			reconciler := newTestReconciler(
+				newNamespace(applied),
+				newNamespaceLabel("team", KeyedNamespace),
+				newNamespaceLabel("duplicate", KeyedNamespace),
+			)
+			before := &corev1.Namespace{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: KeyedNamespace}, before)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: KeyedNamespace}})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			after := &corev1.Namespace{}
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: KeyedNamespace}, after)).To(Succeed())
+			Expect(after.ResourceVersion).Should(Equal(before.ResourceVersion))
+			for _, name := range []string{"team", "duplicate"} {
+				namespaceLabel := &idandanielv1.NamespaceLabel{}
+				Expect(reconciler.Get(ctx, types.NamespacedName{Name: name, Namespace: KeyedNamespace}, namespaceLabel)).To(Succeed())
+				Expect(namespaceLabel.Status.AppliedLabels).Should(Equal(map[string]string{"team": "payments"}))
+			}
+		})
+
+		It("Should not send any write once the Namespace and the statuses are synced", func() {
+			reconciler, counting := newCountingReconciler(KeyedNamespace, 3)
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: KeyedNamespace}}
+
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(counting.writes).Should(BeNumerically(">", 0))
+
+			counting.writes = 0
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(counting.writes).Should(BeZero())
+
+			// The baseline of the benchmarks writes the up to date Namespace again
+			reconciler.Client = &alwaysWritingClient{countingClient: counting}
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(counting.writes).Should(Equal(1))
+		})
+
+		It("Should leave the NamespaceLabels being deleted out of the sync", func() {
+			deleted := newNamespaceLabel("deleted", KeyedNamespace)
+			deleted.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			namespaceLabels := &idandanielv1.NamespaceLabelList{Items: []idandanielv1.NamespaceLabel{
+				*newNamespaceLabel("team", KeyedNamespace), *deleted,
+			}}
+
+			live := withoutDeleted(namespaceLabels)
+			Expect(live.Items).Should(HaveLen(1))
+			Expect(live.Items[0].Name).Should(Equal("team"))
 		})
 	})
 })
@@ -0,0 +1,169 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+)
+
+// labelsFromIndex indexes the NamespaceLabels by the ConfigMaps they take labels from
+const labelsFromIndex = "spec.labelsFrom"
+
+// A label source whose labels are not applied
+type unavailableSource struct {
+	// reason is the reason of the Synced and Ready conditions, SourceNotFound or SourceNotShared
+	reason  string
+	message string
+}
+
+// Labels of the NamespaceLabels which can never be applied, keyed by the NamespaceLabel name and the label key
+type invalidLabels map[string]map[string]idandanielv1.SkippedLabel
+
+func (l invalidLabels) add(namespaceLabel string, key string, value string, reason string, message string) {
+	if l[namespaceLabel] == nil {
+		l[namespaceLabel] = make(map[string]idandanielv1.SkippedLabel)
+	}
+	l[namespaceLabel][key] = idandanielv1.SkippedLabel{Key: key, Value: value, Reason: reason, Message: message}
+}
+
+// Get the invalid keys as the labels to drop from the NamespaceLabels
+func (l invalidLabels) keys() map[string]map[string]string {
+	keys := make(map[string]map[string]string, len(l))
+	for namespaceLabel, skipped := range l {
+		keys[namespaceLabel] = make(map[string]string, len(skipped))
+		for key, label := range skipped {
+			keys[namespaceLabel][key] = label.Message
+		}
+	}
+	return keys
+}
+
+// Render the label templates of the NamespaceLabels and merge in the labels of their sources, returning the updated
+// copy of the list, the labels which can not be applied and the sources which are missing or not shared with the
+// Namespace keyed by the NamespaceLabel name
+func (r *NamespaceLabelReconciler) resolveLabels(ctx context.Context, namespace *corev1.Namespace, namespaceLabels *idandanielv1.NamespaceLabelList) (*idandanielv1.NamespaceLabelList, invalidLabels, map[string][]unavailableSource, error) {
+	rendered, failedTemplates := namespaceLabels.RenderLabels(namespace)
+	invalid := make(invalidLabels)
+	unavailableSources := make(map[string][]unavailableSource)
+	for i := range rendered.Items {
+		namespaceLabel := &rendered.Items[i]
+		for key, message := range failedTemplates[namespaceLabel.GetName()] {
+			invalid.add(namespaceLabel.GetName(), key, namespaceLabel.Spec.Labels[key], idandanielv1.ReasonTemplateFailed, message)
+		}
+		if len(namespaceLabel.Spec.LabelsFrom) == 0 {
+			continue
+		}
+
+		// Later sources override earlier ones, and the labels of the spec override them all
+		labels := make(map[string]string)
+		sourceInvalid := make(map[string]string)
+		for _, source := range namespaceLabel.Spec.LabelsFrom {
+			data, unavailable, err := r.sourceData(ctx, &source, namespaceLabel.GetNamespace())
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if unavailable != nil {
+				unavailableSources[namespaceLabel.GetName()] = append(unavailableSources[namespaceLabel.GetName()], *unavailable)
+				continue
+			}
+			sourceLabels, invalidSourceLabels := source.Labels(data)
+			for key, value := range sourceLabels {
+				labels[key] = value
+				delete(sourceInvalid, key)
+				if message, isInvalid := invalidSourceLabels[key]; isInvalid {
+					sourceInvalid[key] = message
+				}
+			}
+		}
+		for key, value := range namespaceLabel.Spec.Labels {
+			labels[key] = value
+			delete(sourceInvalid, key)
+		}
+		for key, message := range sourceInvalid {
+			invalid.add(namespaceLabel.GetName(), key, labels[key], idandanielv1.ReasonInvalidSourceLabel, message)
+		}
+		namespaceLabel.Spec.Labels = labels
+	}
+
+	return rendered, invalid, unavailableSources, nil
+}
+
+// Read the data of the ConfigMap of a label source of the NamespaceLabels of the Namespace with the SourceReader, the
+// cache only holds the metadata of the ConfigMaps. A ConfigMap which does not exist, or which is in another Namespace
+// and not shared with the Namespace, is returned as unavailable.
+func (r *NamespaceLabelReconciler) sourceData(ctx context.Context, source *idandanielv1.LabelsSource, namespace string) (map[string]string, *unavailableSource, error) {
+	reference := source.Reference(namespace)
+	configMap := &corev1.ConfigMap{}
+	if err := r.sourceReader().Get(ctx, reference, configMap); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return nil, nil, err
+		}
+		return nil, &unavailableSource{
+			reason:  idandanielv1.ReasonSourceNotFound,
+			message: fmt.Sprintf("ConfigMap %s was not found", reference),
+		}, nil
+	}
+
+	// The webhook only checks the user creating the NamespaceLabel may get the ConfigMap, the ConfigMap has to opt in
+	// to be read by the controller on behalf of another Namespace
+	if !idandanielv1.IsSharedWith(configMap, namespace) {
+		return nil, &unavailableSource{
+			reason: idandanielv1.ReasonSourceNotShared,
+			message: fmt.Sprintf("ConfigMap %s is not shared with Namespace %s by its %s annotation",
+				reference, namespace, idandanielv1.SharedWithAnnotation),
+		}, nil
+	}
+
+	if configMap.Data == nil {
+		return map[string]string{}, nil, nil
+	}
+	return configMap.Data, nil, nil
+}
+
+// Index a NamespaceLabel by the ConfigMaps it takes labels from
+func indexLabelsFrom(object client.Object) []string {
+	namespaceLabel, ok := object.(*idandanielv1.NamespaceLabel)
+	if !ok {
+		return nil
+	}
+
+	keys := make([]string, 0, len(namespaceLabel.Spec.LabelsFrom))
+	for _, source := range namespaceLabel.Spec.LabelsFrom {
+		keys = append(keys, source.Reference(namespaceLabel.GetNamespace()).String())
+	}
+	return keys
+}
+
+// Map a ConfigMap to the Namespaces of the NamespaceLabels taking labels from it, so its changes propagate to them
+func (r *NamespaceLabelReconciler) namespaceLabelsForSource(source client.Object) []reconcile.Request {
+	namespaceLabelList := &idandanielv1.NamespaceLabelList{}
+	key := client.ObjectKeyFromObject(source).String()
+	if err := r.List(context.Background(), namespaceLabelList, client.MatchingFields{labelsFromIndex: key}); err != nil {
+		ctrl.Log.WithName("namespacelabel").Error(err, "Failed to list NamespaceLabels taking labels from the source", LabelsSourceField, key)
+		return nil
+	}
+
+	return namespaceRequests(namespaceLabelList)
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("NamespaceLabel Sources", func() {
+	const SourcedNamespace = "sourced"
+
+	ctx := context.Background()
+
+	It("Should merge the labels of the ConfigMaps under the labels of the spec", func() {
+		reconciler := newTestReconciler(
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "costs",
+					Namespace:   "finance",
+					Annotations: map[string]string{idandanielv1.SharedWithAnnotation: "tenant-*," + SourcedNamespace},
+				},
+				Data: map[string]string{"center": "1234", "owner": "finance team", "team": "finance"},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "overrides", Namespace: SourcedNamespace},
+				Data:       map[string]string{"center": "5678"},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "private", Namespace: "finance"},
+				Data:       map[string]string{"budget": "secret"},
+			},
+		)
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: SourcedNamespace}}
+		namespaceLabels := &idandanielv1.NamespaceLabelList{Items: []idandanielv1.NamespaceLabel{{
+			ObjectMeta: metav1.ObjectMeta{Name: "sourced", Namespace: SourcedNamespace},
+			Spec: idandanielv1.NamespaceLabelSpec{
+				Labels: map[string]string{"cost/team": "payments"},
+				LabelsFrom: []idandanielv1.LabelsSource{
+					{Prefix: "cost/", ConfigMapRef: &idandanielv1.SourceReference{Name: "costs", Namespace: "finance"}},
+					{Prefix: "cost/", ConfigMapRef: &idandanielv1.SourceReference{Name: "overrides"}},
+					{ConfigMapRef: &idandanielv1.SourceReference{Name: "private", Namespace: "finance"}},
+					{ConfigMapRef: &idandanielv1.SourceReference{Name: "missing"}},
+				},
+			},
+		}}}
+
+		resolved, invalid, unavailableSources, err := reconciler.resolveLabels(ctx, namespace, namespaceLabels)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resolved.Items[0].Spec.Labels).Should(Equal(map[string]string{
+			"cost/center": "5678",
+			"cost/owner":  "finance team",
+			"cost/team":   "payments",
+		}))
+		Expect(invalid["sourced"]).Should(HaveLen(1))
+		Expect(invalid["sourced"]["cost/owner"].Reason).Should(Equal(idandanielv1.ReasonInvalidSourceLabel))
+		Expect(unavailableSources["sourced"]).Should(Equal([]unavailableSource{
+			{
+				reason:  idandanielv1.ReasonSourceNotShared,
+				message: "ConfigMap finance/private is not shared with Namespace sourced by its idandaniel.idandaniel.io/shared-with annotation",
+			},
+			{reason: idandanielv1.ReasonSourceNotFound, message: "ConfigMap sourced/missing was not found"},
+		}))
+		Expect(namespaceLabels.Items[0].Spec.Labels).Should(HaveLen(1))
+
+		desired, result, err := reconciler.desiredNamespace(ctx, namespace, resolved, invalid, unavailableSources)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(desired.Labels).ShouldNot(HaveKey("cost/owner"))
+		Expect(desired.Labels).ShouldNot(HaveKey("budget"))
+
+		namespaceLabel := &resolved.Items[0]
+		setStatus(namespaceLabel, result)
+		synced := meta.FindStatusCondition(namespaceLabel.Status.Conditions, idandanielv1.ConditionSynced)
+		Expect(synced.Status).Should(Equal(metav1.ConditionFalse))
+		Expect(synced.Reason).Should(Equal(idandanielv1.ReasonSourceNotShared))
+		Expect(synced.Message).Should(HaveSuffix("; ConfigMap sourced/missing was not found"))
+		Expect(namespaceLabel.Status.SkippedLabels).Should(ConsistOf(HaveField("Key", "cost/owner")))
+	})
+
+	It("Should index the NamespaceLabels by their sources", func() {
+		namespaceLabel := &idandanielv1.NamespaceLabel{
+			ObjectMeta: metav1.ObjectMeta{Name: "sourced", Namespace: SourcedNamespace},
+			Spec: idandanielv1.NamespaceLabelSpec{LabelsFrom: []idandanielv1.LabelsSource{
+				{ConfigMapRef: &idandanielv1.SourceReference{Name: "costs", Namespace: "finance"}},
+				{ConfigMapRef: &idandanielv1.SourceReference{Name: "overrides"}},
+			}},
+		}
+
+		Expect(indexLabelsFrom(namespaceLabel)).Should(Equal([]string{"finance/costs", "sourced/overrides"}))
+	})
+})
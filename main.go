@@ -17,21 +17,31 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	idandanielv1 "idandaniel.io/namespacelabel-demo/api/v1"
+	idandanielv2 "idandaniel.io/namespacelabel-demo/api/v2"
+	"idandaniel.io/namespacelabel-demo/common/logging"
+	"idandaniel.io/namespacelabel-demo/common/protected"
 	"idandaniel.io/namespacelabel-demo/controllers"
 	//+kubebuilder:scaffold:imports
 )
@@ -45,22 +55,89 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(idandanielv1.AddToScheme(scheme))
+	utilruntime.Must(idandanielv2.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
+// Builds the protected label or annotation rules from the flags and the optional ConfigMap
+func loadProtectedRules(
+	ctx context.Context,
+	reader client.Reader,
+	keys, prefixes, patterns, configMapName string,
+	fromConfigMap func(*corev1.ConfigMap) (*protected.Rules, error),
+) (*protected.Rules, error) {
+	rules, err := protected.NewRules(protected.SplitList(keys), protected.SplitList(prefixes), protected.SplitList(patterns))
+	if err != nil {
+		return nil, err
+	}
+
+	if configMapName == "" {
+		return rules, nil
+	}
+
+	namespace, name, found := strings.Cut(configMapName, "/")
+	if !found {
+		return nil, fmt.Errorf("protected rules ConfigMap %q is not in the <namespace>/<name> format", configMapName)
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, configMap); err != nil {
+		return nil, err
+	}
+	configMapRules, err := fromConfigMap(configMap)
+	if err != nil {
+		return nil, err
+	}
+
+	return rules.Merge(configMapRules), nil
+}
+
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var protectedLabelKeys string
+	var protectedLabelPrefixes string
+	var protectedLabelPatterns string
+	var protectedLabelsConfigMap string
+	var protectedAnnotationKeys string
+	var protectedAnnotationPrefixes string
+	var protectedAnnotationPatterns string
+	var forceLabelOwnership bool
+	var dryRun bool
+	var orphanCollectionInterval time.Duration
+	var resyncPeriod time.Duration
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&protectedLabelKeys, "protected-label-keys", "",
+		"Comma separated label keys NamespaceLabels may never change.")
+	flag.StringVar(&protectedLabelPrefixes, "protected-label-prefixes", strings.Join(protected.DefaultPrefixes, ","),
+		"Comma separated label DNS prefixes NamespaceLabels may never change, subdomains are protected as well.")
+	flag.StringVar(&protectedLabelPatterns, "protected-label-patterns", "",
+		"Comma separated regular expressions of label keys NamespaceLabels may never change.")
+	flag.StringVar(&protectedAnnotationKeys, "protected-annotation-keys", "",
+		"Comma separated annotation keys NamespaceLabels may never change.")
+	flag.StringVar(&protectedAnnotationPrefixes, "protected-annotation-prefixes", strings.Join(protected.DefaultAnnotationPrefixes, ","),
+		"Comma separated annotation DNS prefixes NamespaceLabels may never change, subdomains are protected as well.")
+	flag.StringVar(&protectedAnnotationPatterns, "protected-annotation-patterns", "",
+		"Comma separated regular expressions of annotation keys NamespaceLabels may never change.")
+	flag.StringVar(&protectedLabelsConfigMap, "protected-labels-configmap", "",
+		"A <namespace>/<name> ConfigMap with additional protected label and annotation keys, prefixes and patterns.")
+	flag.BoolVar(&forceLabelOwnership, "force-label-ownership", false,
+		"Take over Namespace labels managed by other field managers instead of reporting a conflict.")
+	flag.BoolVar(&dryRun, "dry-run", false,
+		"Audit every NamespaceLabel, reporting the changes in their status and events without changing Namespaces.")
+	flag.DurationVar(&orphanCollectionInterval, "orphan-collection-interval", time.Hour,
+		"Interval between the removals of the labels left by NamespaceLabels deleted without their finalizer, 0 only removes them at startup.")
+	flag.DurationVar(&resyncPeriod, "resync-period", 10*time.Minute,
+		"Interval between two syncs of a NamespaceLabel when nothing changed, with up to 10% of jitter, 0 only syncs on changes.")
 	opts := zap.Options{
 		Development: true,
 	}
 	opts.BindFlags(flag.CommandLine)
+	logging.BindFlags(flag.CommandLine, &opts)
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
@@ -89,13 +166,82 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err = (&controllers.NamespaceLabelReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
+	protectedLabels, err := loadProtectedRules(
+		context.Background(),
+		mgr.GetAPIReader(),
+		protectedLabelKeys,
+		protectedLabelPrefixes,
+		protectedLabelPatterns,
+		protectedLabelsConfigMap,
+		protected.RulesFromConfigMap,
+	)
+	if err != nil {
+		setupLog.Error(err, "unable to load protected labels")
+		os.Exit(1)
+	}
+	protectedAnnotations, err := loadProtectedRules(
+		context.Background(),
+		mgr.GetAPIReader(),
+		protectedAnnotationKeys,
+		protectedAnnotationPrefixes,
+		protectedAnnotationPatterns,
+		protectedLabelsConfigMap,
+		protected.AnnotationRulesFromConfigMap,
+	)
+	if err != nil {
+		setupLog.Error(err, "unable to load protected annotations")
+		os.Exit(1)
+	}
+
+	namespaceLabelReconciler := &controllers.NamespaceLabelReconciler{
+		Client:               mgr.GetClient(),
+		Scheme:               mgr.GetScheme(),
+		Recorder:             mgr.GetEventRecorderFor("namespacelabel-controller"),
+		SourceReader:         mgr.GetAPIReader(),
+		ProtectedLabels:      protectedLabels,
+		ProtectedAnnotations: protectedAnnotations,
+		ForceOwnership:       forceLabelOwnership,
+		DryRun:               dryRun,
+		ResyncPeriod:         resyncPeriod,
+	}
+	if err = namespaceLabelReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "NamespaceLabel")
 		os.Exit(1)
 	}
+	if err = (&controllers.ClusterNamespaceLabelReconciler{
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		Recorder:        mgr.GetEventRecorderFor("clusternamespacelabel-controller"),
+		NamespaceLabels: namespaceLabelReconciler,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterNamespaceLabel")
+		os.Exit(1)
+	}
+	if err = (&controllers.GarbageCollector{
+		NamespaceLabels: namespaceLabelReconciler,
+		Interval:        orphanCollectionInterval,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to add the garbage collector")
+		os.Exit(1)
+	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&idandanielv1.NamespaceLabelValidator{
+			Client:               mgr.GetClient(),
+			ProtectedLabels:      protectedLabels,
+			ProtectedAnnotations: protectedAnnotations,
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "NamespaceLabel")
+			os.Exit(1)
+		}
+		if err = (&idandanielv1.NamespaceLabelPolicyValidator{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "NamespaceLabelPolicy")
+			os.Exit(1)
+		}
+		if err = (&idandanielv2.NamespaceLabel{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "NamespaceLabel")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {